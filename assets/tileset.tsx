@@ -1,4 +1,164 @@
 <?xml version="1.0" encoding="UTF-8"?>
 <tileset version="1.8" tiledversion="1.8.4" name="tileset" tilewidth="24" tileheight="24" tilecount="56" columns="8">
  <image source="tileset.png" width="192" height="168"/>
+ <tile id="8">
+  <properties>
+   <property name="wall" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="9">
+  <properties>
+   <property name="wall" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="10">
+  <properties>
+   <property name="wall" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="11">
+  <properties>
+   <property name="wall" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="16">
+  <properties>
+   <property name="floor" type="bool" value="true"/>
+   <property name="theme" type="int" value="1"/>
+  </properties>
+ </tile>
+ <tile id="17">
+  <properties>
+   <property name="floor" type="bool" value="true"/>
+   <property name="theme" type="int" value="2"/>
+  </properties>
+ </tile>
+ <tile id="18">
+  <properties>
+   <property name="floor" type="bool" value="true"/>
+   <property name="theme" type="int" value="3"/>
+  </properties>
+ </tile>
+ <tile id="24">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="theme" type="int" value="1"/>
+  </properties>
+ </tile>
+ <tile id="25">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="theme" type="int" value="2"/>
+  </properties>
+ </tile>
+ <tile id="26">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="theme" type="int" value="3"/>
+  </properties>
+ </tile>
+ <tile id="32">
+  <properties>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="1"/>
+  </properties>
+ </tile>
+ <tile id="33">
+  <properties>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="2"/>
+  </properties>
+ </tile>
+ <tile id="34">
+  <properties>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="3"/>
+  </properties>
+ </tile>
+ <tile id="35">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="1"/>
+  </properties>
+ </tile>
+ <tile id="36">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="2"/>
+  </properties>
+ </tile>
+ <tile id="37">
+  <properties>
+   <property name="goal" type="bool" value="true"/>
+   <property name="player" type="bool" value="true"/>
+   <property name="theme" type="int" value="3"/>
+  </properties>
+ </tile>
+ <tile id="40">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="onGoal" type="int" value="48"/>
+  </properties>
+ </tile>
+ <tile id="41">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="onGoal" type="int" value="49"/>
+  </properties>
+ </tile>
+ <tile id="42">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="onGoal" type="int" value="50"/>
+  </properties>
+ </tile>
+ <tile id="43">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="onGoal" type="int" value="51"/>
+  </properties>
+ </tile>
+ <tile id="44">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="onGoal" type="int" value="52"/>
+  </properties>
+ </tile>
+ <tile id="48">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="goal" type="bool" value="true"/>
+   <property name="offGoal" type="int" value="40"/>
+  </properties>
+ </tile>
+ <tile id="49">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="goal" type="bool" value="true"/>
+   <property name="offGoal" type="int" value="41"/>
+  </properties>
+ </tile>
+ <tile id="50">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="goal" type="bool" value="true"/>
+   <property name="offGoal" type="int" value="42"/>
+  </properties>
+ </tile>
+ <tile id="51">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="goal" type="bool" value="true"/>
+   <property name="offGoal" type="int" value="43"/>
+  </properties>
+ </tile>
+ <tile id="52">
+  <properties>
+   <property name="box" type="bool" value="true"/>
+   <property name="goal" type="bool" value="true"/>
+   <property name="offGoal" type="int" value="44"/>
+  </properties>
+ </tile>
 </tileset>
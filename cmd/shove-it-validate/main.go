@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/validate"
+	"github.com/pkg/errors"
+)
+
+var errNoPath = errors.New("usage: shove-it-validate [-solve] [-timeout 10s] dir|file")
+
+// shove-it-validate checks the stages of a pack on disk like `shove-it validate` does, without the game,
+// so it runs without a display.
+func main() {
+	solve := flag.Bool("solve", false, "run the solver on every valid stage")
+	timeout := flag.Duration("timeout", validate.DefaultSolveTimeout, "time budget of the solver per stage")
+	flag.Parse()
+
+	if err := run(flag.Arg(0), *solve, *timeout); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(name string, solve bool, timeout time.Duration) error {
+	if name == "" {
+		return errNoPath
+	}
+
+	pack, err := level.LoadPackPath(name)
+	if err != nil {
+		return errors.Wrap(err, "error on load pack")
+	}
+
+	return validate.Pack(os.Stdout, pack, solve, timeout)
+}
@@ -4,7 +4,9 @@ go 1.17
 
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.3.0
+	github.com/klauspost/compress v1.15.15
 	github.com/pkg/errors v0.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,234 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AsepriteForward         = "forward"
+	AsepriteReverse         = "reverse"
+	AsepritePingPong        = "pingpong"
+	AsepritePingPongReverse = "pingpong_reverse"
+)
+
+var (
+	ErrNoFrameTags      = errors.New("sheet has no frame tags")
+	ErrTagOutside       = errors.New("frame tag is outside of frames")
+	ErrUnknownDirection = errors.New("unknown frame tag direction")
+	ErrTrimmedFrame     = errors.New("trimmed frames aren't supported, export the sheet without trim")
+	ErrInvalidRepeat    = errors.New("invalid frame tag repeat")
+)
+
+// AsepriteSheet is the JSON data of a sprite sheet exported by Aseprite, as a hash or an array of frames.
+type AsepriteSheet struct {
+	Frames AsepriteFrames `json:"frames"`
+	Meta   AsepriteMeta   `json:"meta"`
+}
+
+type AsepriteFrame struct {
+	Filename string       `json:"filename"`
+	Frame    AsepriteRect `json:"frame"`
+	Trimmed  bool         `json:"trimmed"`
+	Duration int          `json:"duration"`
+}
+
+type AsepriteRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// AsepriteMeta has the sheet image, relative to the JSON file, and the frame tags.
+type AsepriteMeta struct {
+	Image     string        `json:"image"`
+	FrameTags []AsepriteTag `json:"frameTags"`
+}
+
+// AsepriteTag is an animation of frames From to To. Repeat is how many times it's played, forever when empty.
+type AsepriteTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+	Repeat    string `json:"repeat"`
+}
+
+// AsepriteFrames keeps frames in the order of the file, which is the frame number also when they're a hash.
+type AsepriteFrames []AsepriteFrame
+
+func (frames *AsepriteFrames) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		var list []AsepriteFrame
+
+		err := json.Unmarshal(b, &list)
+		if err != nil {
+			return errors.Wrap(err, "error on decode frame array")
+		}
+
+		*frames = list
+
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	_, err := dec.Token()
+	if err != nil {
+		return errors.Wrap(err, "error on read frame hash")
+	}
+
+	res := make(AsepriteFrames, 0)
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return errors.Wrap(err, "error on read frame name")
+		}
+
+		var frame AsepriteFrame
+
+		err = dec.Decode(&frame)
+		if err != nil {
+			return errors.Wrapf(err, "error on decode frame '%v'", key)
+		}
+
+		frame.Filename, _ = key.(string)
+		res = append(res, frame)
+	}
+
+	*frames = res
+
+	return nil
+}
+
+// loadAseprite reads a sheet exported by Aseprite, with a sprite named after each frame tag.
+func loadAseprite(assets fs.FS, filename string) (map[SpriteName]AtlasSprite, error) {
+	b, err := fs.ReadFile(assets, filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read file from assets")
+	}
+
+	var sheet AsepriteSheet
+
+	err = json.Unmarshal(b, &sheet)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on decode sheet")
+	}
+
+	if len(sheet.Meta.FrameTags) == 0 {
+		return nil, ErrNoFrameTags
+	}
+
+	for i, frame := range sheet.Frames {
+		if frame.Trimmed {
+			return nil, errors.Wrapf(ErrTrimmedFrame, "frame %d", i)
+		}
+	}
+
+	res := make(map[SpriteName]AtlasSprite, len(sheet.Meta.FrameTags))
+
+	for _, tag := range sheet.Meta.FrameTags {
+		if _, ok := res[SpriteName(tag.Name)]; ok {
+			return nil, errors.Wrapf(ErrDuplicateSprite, "'%s'", tag.Name)
+		}
+
+		def, err := tag.sprite(sheet.Frames)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error on frame tag '%s'", tag.Name)
+		}
+
+		def.Image = path.Join(path.Dir(filename), sheet.Meta.Image)
+		res[SpriteName(tag.Name)] = def
+	}
+
+	return res, nil
+}
+
+// sprite lays the frames of the tag out for the atlas. Tags played forever loop over a single pass,
+// others have every pass written out and stop on the last frame.
+func (tag AsepriteTag) sprite(frames AsepriteFrames) (AtlasSprite, error) {
+	if tag.From < 0 || tag.To < tag.From || tag.To >= len(frames) {
+		return AtlasSprite{}, errors.Wrapf(ErrTagOutside, "%d to %d of %d frames", tag.From, tag.To, len(frames))
+	}
+
+	forward := make([]int, 0, tag.To-tag.From+1)
+	for i := tag.From; i <= tag.To; i++ {
+		forward = append(forward, i)
+	}
+
+	reverse := make([]int, len(forward))
+	for i := range forward {
+		reverse[i] = forward[len(forward)-1-i]
+	}
+
+	var (
+		first, second []int
+		loop          string
+	)
+
+	switch tag.Direction {
+	case AsepriteForward, "":
+		first, second, loop = forward, forward, LoopForward
+	case AsepriteReverse:
+		first, second, loop = reverse, reverse, LoopForward
+	case AsepritePingPong:
+		first, second, loop = forward, reverse, LoopPingPong
+	case AsepritePingPongReverse:
+		first, second, loop = reverse, forward, LoopPingPong
+	default:
+		return AtlasSprite{}, errors.Wrapf(ErrUnknownDirection, "'%s'", tag.Direction)
+	}
+
+	order := first
+
+	if tag.Repeat != "" {
+		repeat, err := strconv.Atoi(tag.Repeat)
+		if err != nil || repeat < 1 {
+			return AtlasSprite{}, errors.Wrapf(ErrInvalidRepeat, "'%s'", tag.Repeat)
+		}
+
+		order = make([]int, 0, len(first)*repeat)
+
+		for pass := 0; pass < repeat; pass++ {
+			switch {
+			case pass%2 == 0 && loop == LoopPingPong && pass > 0:
+				order = append(order, first[1:]...)
+			case pass%2 == 1 && loop == LoopPingPong:
+				order = append(order, second[1:]...)
+			case pass%2 == 0:
+				order = append(order, first...)
+			default:
+				order = append(order, second...)
+			}
+		}
+
+		loop = LoopOnce
+	}
+
+	res := AtlasSprite{
+		Image:  "",
+		Loop:   loop,
+		Frames: make([]AtlasFrame, len(order)),
+	}
+
+	for i, index := range order {
+		frame := frames[index]
+		res.Frames[i] = AtlasFrame{
+			X:        frame.Frame.X,
+			Y:        frame.Frame.Y,
+			W:        frame.Frame.W,
+			H:        frame.Frame.H,
+			Duration: frame.Duration,
+		}
+	}
+
+	return res, nil
+}
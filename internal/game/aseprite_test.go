@@ -0,0 +1,217 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+)
+
+func asepriteFrames(count int) AsepriteFrames {
+	res := make(AsepriteFrames, count)
+	for i := range res {
+		res[i] = AsepriteFrame{Filename: "", Frame: AsepriteRect{X: i * 24, Y: 0, W: 24, H: 24}, Trimmed: false, Duration: 100}
+	}
+
+	return res
+}
+
+func TestAsepriteTagSprite(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    AsepriteTag
+		frames []int
+		loop   string
+		err    error
+	}{
+		{
+			name:   "forward",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepriteForward, Repeat: ""},
+			frames: []int{0, 1, 2},
+			loop:   LoopForward,
+			err:    nil,
+		},
+		{
+			name:   "no direction",
+			tag:    AsepriteTag{Name: "walking", From: 1, To: 2, Direction: "", Repeat: ""},
+			frames: []int{1, 2},
+			loop:   LoopForward,
+			err:    nil,
+		},
+		{
+			name:   "reverse",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepriteReverse, Repeat: ""},
+			frames: []int{2, 1, 0},
+			loop:   LoopForward,
+			err:    nil,
+		},
+		{
+			name:   "ping-pong",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepritePingPong, Repeat: ""},
+			frames: []int{0, 1, 2},
+			loop:   LoopPingPong,
+			err:    nil,
+		},
+		{
+			name:   "ping-pong reverse",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepritePingPongReverse, Repeat: ""},
+			frames: []int{2, 1, 0},
+			loop:   LoopPingPong,
+			err:    nil,
+		},
+		{
+			name:   "repeat",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepriteForward, Repeat: "2"},
+			frames: []int{0, 1, 2, 0, 1, 2},
+			loop:   LoopOnce,
+			err:    nil,
+		},
+		{
+			name:   "ping-pong repeat",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepritePingPong, Repeat: "3"},
+			frames: []int{0, 1, 2, 1, 0, 1, 2},
+			loop:   LoopOnce,
+			err:    nil,
+		},
+		{
+			name:   "past the last frame",
+			tag:    AsepriteTag{Name: "walking", From: 2, To: 3, Direction: AsepriteForward, Repeat: ""},
+			frames: nil,
+			loop:   "",
+			err:    ErrTagOutside,
+		},
+		{
+			name:   "backwards",
+			tag:    AsepriteTag{Name: "walking", From: 2, To: 1, Direction: AsepriteForward, Repeat: ""},
+			frames: nil,
+			loop:   "",
+			err:    ErrTagOutside,
+		},
+		{
+			name:   "unknown direction",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: "sideways", Repeat: ""},
+			frames: nil,
+			loop:   "",
+			err:    ErrUnknownDirection,
+		},
+		{
+			name:   "zero repeat",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepriteForward, Repeat: "0"},
+			frames: nil,
+			loop:   "",
+			err:    ErrInvalidRepeat,
+		},
+		{
+			name:   "repeat not a number",
+			tag:    AsepriteTag{Name: "walking", From: 0, To: 2, Direction: AsepriteForward, Repeat: "twice"},
+			frames: nil,
+			loop:   "",
+			err:    ErrInvalidRepeat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sprite, err := tt.tag.sprite(asepriteFrames(3))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("sprite() error = %v, want %v", err, tt.err)
+			}
+
+			var frames []int
+			for _, frame := range sprite.Frames {
+				frames = append(frames, frame.X/24)
+			}
+
+			if !reflect.DeepEqual(frames, tt.frames) || sprite.Loop != tt.loop {
+				t.Errorf("sprite() = %v %q, want %v %q", frames, sprite.Loop, tt.frames, tt.loop)
+			}
+		})
+	}
+}
+
+func TestLoadAseprite(t *testing.T) {
+	tags := `"meta": {"image": "player.png", "frameTags": [
+		{"name": "idle", "from": 0, "to": 0, "direction": "forward"},
+		{"name": "walking", "from": 0, "to": 1, "direction": "forward"}
+	]}`
+
+	tests := []struct {
+		name   string
+		data   string
+		sprite AtlasSprite
+		err    error
+	}{
+		{
+			name: "frame hash",
+			data: `{"frames": {
+				"player 1.ase": {"frame": {"x": 24, "y": 0, "w": 24, "h": 32}, "duration": 100},
+				"player 0.ase": {"frame": {"x": 0, "y": 0, "w": 24, "h": 32}, "duration": 200}
+			}, ` + tags + `}`,
+			sprite: AtlasSprite{Image: "sprites/player.png", Loop: LoopForward, Frames: []AtlasFrame{
+				{X: 24, Y: 0, W: 24, H: 32, Duration: 100},
+				{X: 0, Y: 0, W: 24, H: 32, Duration: 200},
+			}},
+			err: nil,
+		},
+		{
+			name: "frame array",
+			data: `{"frames": [
+				{"filename": "player 0.ase", "frame": {"x": 0, "y": 0, "w": 24, "h": 32}, "duration": 200},
+				{"filename": "player 1.ase", "frame": {"x": 24, "y": 0, "w": 24, "h": 32}, "duration": 100}
+			], ` + tags + `}`,
+			sprite: AtlasSprite{Image: "sprites/player.png", Loop: LoopForward, Frames: []AtlasFrame{
+				{X: 0, Y: 0, W: 24, H: 32, Duration: 200},
+				{X: 24, Y: 0, W: 24, H: 32, Duration: 100},
+			}},
+			err: nil,
+		},
+		{
+			name:   "no frame tags",
+			data:   `{"frames": [{"frame": {"x": 0, "y": 0, "w": 24, "h": 32}, "duration": 100}], "meta": {"image": "player.png"}}`,
+			sprite: AtlasSprite{Image: "", Loop: "", Frames: nil},
+			err:    ErrNoFrameTags,
+		},
+		{
+			name: "trimmed frame",
+			data: `{"frames": [
+				{"frame": {"x": 0, "y": 0, "w": 20, "h": 32}, "trimmed": true, "duration": 100},
+				{"frame": {"x": 20, "y": 0, "w": 24, "h": 32}, "duration": 100}
+			], ` + tags + `}`,
+			sprite: AtlasSprite{Image: "", Loop: "", Frames: nil},
+			err:    ErrTrimmedFrame,
+		},
+		{
+			name: "same tag twice",
+			data: `{"frames": [{"frame": {"x": 0, "y": 0, "w": 24, "h": 32}, "duration": 100}], "meta": {"image": "player.png", "frameTags": [
+				{"name": "idle", "from": 0, "to": 0},
+				{"name": "idle", "from": 0, "to": 0}
+			]}}`,
+			sprite: AtlasSprite{Image: "", Loop: "", Frames: nil},
+			err:    ErrDuplicateSprite,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"sprites/player.json": &fstest.MapFile{Data: []byte(tt.data)}}
+
+			sprites, err := loadAseprite(fsys, "sprites/player.json")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("loadAseprite() error = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(sprites) != 2 || len(sprites[SpriteIdle].Frames) != 1 {
+				t.Errorf("loadAseprite() = %v, want idle and walking", sprites)
+			}
+
+			if !reflect.DeepEqual(sprites[SpriteWalking], tt.sprite) {
+				t.Errorf("walking = %+v, want %+v", sprites[SpriteWalking], tt.sprite)
+			}
+		})
+	}
+}
@@ -1,15 +1,18 @@
 package game
 
 import (
-	"math"
-
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
 )
 
+const deadlockTint = .4
+
 type Box struct {
 	PositionX, PositionY float64
 	I, J                 int
 	SpriteName           SpriteName
+	GoalSprite           SpriteName
+	deadlocked           bool
 }
 
 func (box Box) DesiredX() float64 {
@@ -21,22 +24,11 @@ func (box Box) DesiredY() float64 {
 }
 
 func (box *Box) Update(game *Game) {
-	if box.DesiredX() != box.PositionX {
-		if math.Signbit(box.DesiredX() - box.PositionX) {
-			box.PositionX -= movementSpeed
-		} else {
-			box.PositionX += movementSpeed
-		}
-
-		game.shouldDraw = true
-	}
+	if box.DesiredX() != box.PositionX || box.DesiredY() != box.PositionY {
+		speed := movementSpeed * game.speed()
 
-	if box.DesiredY() != box.PositionY {
-		if math.Signbit(box.DesiredY() - box.PositionY) {
-			box.PositionY -= movementSpeed
-		} else {
-			box.PositionY += movementSpeed
-		}
+		box.PositionX = approach(box.PositionX, box.DesiredX(), speed)
+		box.PositionY = approach(box.PositionY, box.DesiredY(), speed)
 
 		game.shouldDraw = true
 	}
@@ -60,6 +52,8 @@ func (box *Box) Draw(game *Game, screen *ebiten.Image) {
 
 	if box.Done(game) {
 		switch {
+		case box.GoalSprite != "":
+			currentSprite = box.GoalSprite
 		case box.SpriteName == SpriteBox1:
 			currentSprite = SpriteBoxDone1
 		case box.SpriteName == SpriteBox2:
@@ -73,65 +67,13 @@ func (box *Box) Draw(game *Game, screen *ebiten.Image) {
 		}
 	}
 
-	screen.DrawImage(game.sprites[currentSprite].Images[0], &opts)
-}
-
-func (box *Box) Done(game *Game) bool {
-	return game.stages[game.stageIndex].IsFlag(box.I, box.J) && box.DesiredX() == box.PositionX && box.DesiredY() == box.PositionY
-}
-
-func (box *Box) IsWallAtLeft(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(box.I-1, box.J)
-}
-
-func (box *Box) IsWallAtRight(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(box.I+1, box.J)
-}
-
-func (box *Box) IsWallAtTop(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(box.I, box.J-1)
-}
-
-func (box *Box) IsWallAtBottom(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(box.I, box.J+1)
-}
-
-func (box *Box) IsBoxAtLeft(game *Game) bool {
-	for j := range game.boxes {
-		if game.boxes[j].I == box.I-1 && game.boxes[j].J == box.J {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (box *Box) IsBoxAtRight(game *Game) bool {
-	for j := range game.boxes {
-		if game.boxes[j].I == box.I+1 && game.boxes[j].J == box.J {
-			return true
-		}
-	}
-
-	return false
-}
-
-func (box *Box) IsBoxAtTop(game *Game) bool {
-	for j := range game.boxes {
-		if game.boxes[j].I == box.I && game.boxes[j].J == box.J-1 {
-			return true
-		}
+	if box.deadlocked {
+		opts.ColorM.Scale(1, deadlockTint, deadlockTint, 1)
 	}
 
-	return false
+	screen.DrawImage(game.sprites[currentSprite].Images[0], &opts)
 }
 
-func (box *Box) IsBoxAtBottom(game *Game) bool {
-	for j := range game.boxes {
-		if game.boxes[j].I == box.I && game.boxes[j].J == box.J+1 {
-			return true
-		}
-	}
-
-	return false
+func (box *Box) Done(game *Game) bool {
+	return game.state.IsGoal(sokoban.Position{I: box.I, J: box.J}) && box.DesiredX() == box.PositionX && box.DesiredY() == box.PositionY
 }
@@ -0,0 +1,138 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	completeTitleY  = 4
+	completeLabelX  = 8
+	completeValueX  = 16
+	completeBestX   = 24
+	completeMovesY  = 8
+	completePushesY = 9
+	completeTimeY   = 10
+	completeNoteY   = 12
+	completeMenuY   = 16
+)
+
+const (
+	completeContinue = iota
+	completeRetry
+	completeReplay
+)
+
+// stageResult is a completed run and the stage records before it.
+type stageResult struct {
+	moves, pushes int
+	ticks         int
+	previous      StageRecord
+	replayed      bool
+}
+
+func (res stageResult) newBest() bool {
+	if res.replayed {
+		return false
+	}
+
+	return !res.previous.Completed || res.moves < res.previous.BestMoves || res.pushes < res.previous.BestPushes
+}
+
+func formatTicks(ticks int) string {
+	seconds := ticks / fps
+
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+func formatCount(n int) string {
+	if n <= 0 {
+		return "-"
+	}
+
+	return fmt.Sprint(n)
+}
+
+// completeScene is shown once all boxes of a stage are on flags.
+type completeScene struct {
+	result stageResult
+	menu   *menu
+}
+
+func newCompleteScene(result stageResult) *completeScene {
+	return &completeScene{
+		result: result,
+		menu:   newMenu("CONTINUE", "RETRY", "WATCH REPLAY"),
+	}
+}
+
+func (scene *completeScene) Update(game *Game, actions []Action) error {
+	switch scene.menu.Update(game, actions) {
+	case completeContinue:
+		game.nextStage()
+		game.SetScene(newPlayScene())
+	case completeRetry:
+		game.startStage()
+		game.SetScene(newPlayScene())
+	case completeReplay:
+		if !game.startReplay() {
+			game.startStage()
+		}
+
+		game.SetScene(newPlayScene())
+	}
+
+	return nil
+}
+
+func (scene *completeScene) Draw(game *Game, screen *ebiten.Image) {
+	clearScreen(screen)
+
+	stage := game.stages[game.stageIndex]
+	res := scene.result
+
+	game.DrawTextCentered(screen, completeTitleY, stage.Label()+" CLEAR")
+
+	game.DrawText(screen, completeBestX, completeMovesY-1, "BEST")
+	game.DrawText(screen, completeBestX+len("BEST")+2, completeMovesY-1, "PAR")
+
+	rows := []struct {
+		posY       int
+		label      string
+		value      int
+		best, par  int
+		bestExists bool
+	}{
+		{posY: completeMovesY, label: "MOVES", value: res.moves, best: res.previous.BestMoves, par: stage.ParMoves, bestExists: res.previous.Completed},
+		{posY: completePushesY, label: "PUSHES", value: res.pushes, best: res.previous.BestPushes, par: stage.ParPushes, bestExists: res.previous.Completed},
+	}
+
+	for _, row := range rows {
+		best := "-"
+		if row.bestExists {
+			best = fmt.Sprint(row.best)
+		}
+
+		game.DrawText(screen, completeLabelX, row.posY, row.label)
+		game.DrawText(screen, completeValueX, row.posY, fmt.Sprint(row.value))
+		game.DrawText(screen, completeBestX, row.posY, best)
+		game.DrawText(screen, completeBestX+len("BEST")+2, row.posY, formatCount(row.par))
+	}
+
+	game.DrawText(screen, completeLabelX, completeTimeY, "TIME")
+	game.DrawText(screen, completeValueX, completeTimeY, formatTicks(res.ticks))
+
+	switch {
+	case res.replayed:
+		game.DrawTextCentered(screen, completeNoteY, "REPLAY")
+	case res.newBest():
+		game.DrawTextCentered(screen, completeNoteY, "NEW BEST!")
+	}
+
+	scene.menu.Draw(game, screen, completeMenuY)
+}
+
+func (*completeScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
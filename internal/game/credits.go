@@ -0,0 +1,82 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	creditsTitleY = 1
+	creditsListY  = 4
+	creditsRows   = 22
+)
+
+// creditsScene shows title, author, license and credits of every pack.
+type creditsScene struct {
+	lines []string
+	top   int
+}
+
+func newCreditsScene(game *Game) *creditsScene {
+	lines := make([]string, 0)
+
+	for i, pack := range game.packs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, pack.Label())
+
+		if pack.Author != "" {
+			lines = append(lines, "BY "+pack.Author)
+		}
+
+		if pack.License != "" {
+			lines = append(lines, "LICENSE "+pack.License)
+		}
+
+		lines = append(lines, pack.Credits...)
+	}
+
+	return &creditsScene{
+		lines: lines,
+		top:   0,
+	}
+}
+
+func (scene *creditsScene) Update(game *Game, actions []Action) error {
+	for _, action := range actions {
+		switch action {
+		case ActionMenuUp:
+			if scene.top > 0 {
+				scene.top--
+				game.shouldDraw = true
+			}
+		case ActionMenuDown:
+			if scene.top+creditsRows < len(scene.lines) {
+				scene.top++
+				game.shouldDraw = true
+			}
+		case ActionBack, ActionConfirm:
+			game.SetScene(newTitleScene())
+
+			return nil
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (scene *creditsScene) Draw(game *Game, screen *ebiten.Image) {
+	clearScreen(screen)
+
+	game.DrawTextCentered(screen, creditsTitleY, "CREDITS")
+
+	for row := 0; row < creditsRows && scene.top+row < len(scene.lines); row++ {
+		game.DrawTextCentered(screen, creditsListY+row, scene.lines[scene.top+row])
+	}
+}
+
+func (*creditsScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,35 @@
+package game
+
+// checkDeadlock marks boxes which can't reach a goal anymore
+// and remembers the last position before the first of them got stuck.
+func (game *Game) checkDeadlock() {
+	for i := range game.boxes {
+		game.boxes[i].deadlocked = false
+	}
+
+	deadlocked := game.state.Deadlocked()
+
+	for _, i := range deadlocked {
+		game.boxes[i].deadlocked = true
+	}
+
+	switch {
+	case len(deadlocked) == 0:
+		game.deadlockAt = -1
+	case game.deadlockAt < 0:
+		game.deadlockAt = game.state.Moves() - 1
+	}
+
+	if game.deadlockAt >= 0 {
+		game.setMessage("DEADLOCK! PRESS U TO UNDO")
+	}
+}
+
+// undoDeadlock rewinds to the position before the current deadlock.
+func (game *Game) undoDeadlock() {
+	if game.deadlockAt < 0 {
+		return
+	}
+
+	game.rewind(game.state.Moves() - game.deadlockAt)
+}
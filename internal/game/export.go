@@ -0,0 +1,29 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"path"
+)
+
+const exportDir = "exports"
+
+func exportName(stage string) string {
+	return path.Join(exportDir, keyFileName(stage)+".txt")
+}
+
+// export hands the current stage in XSB and the moves made in LURD to the player, where exportData
+// puts them, and tells where on screen.
+func (game *Game) export() {
+	b := []byte(fmt.Sprintf("%s\n%s\n", game.StageXSB(), game.SolutionLURD()))
+
+	where, err := exportData(exportName(game.stages[game.stageIndex].Key()), b)
+	if err != nil {
+		log.Printf("error on export stage: %v", err)
+		game.setMessage("EXPORT FAILED")
+
+		return
+	}
+
+	game.setMessage("EXPORTED TO " + where)
+}
@@ -2,19 +2,28 @@ package game
 
 import (
 	"image"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 const (
-	characterWidth = 8
-	characterSkip  = 32
+	characterWidth   = 8
+	characterSkip    = 32
+	lastCharacter    = '_'
+	missingCharacter = '?'
 )
 
-// DrawText renders text on screen.
+// DrawText renders text on screen, a column per character.
 // x and y are base on 40x28 dimension indexing.
+// Font has only upper case letters, so text is upper cased, and characters it lacks are drawn as question marks.
 func (game *Game) DrawText(screen *ebiten.Image, posX, posY int, text string) {
-	for i, c := range text {
+	for i, c := range []rune(strings.ToUpper(text)) {
+		if c < characterSkip || c > lastCharacter {
+			c = missingCharacter
+		}
+
 		if _, ok := game.fontCache[c]; !ok {
 			cx := (int(c) - characterSkip) * characterWidth
 
@@ -29,3 +38,13 @@ func (game *Game) DrawText(screen *ebiten.Image, posX, posY int, text string) {
 		screen.DrawImage(game.fontCache[c], opts)
 	}
 }
+
+// DrawTextCentered renders text horizontally centered on row posY.
+func (game *Game) DrawTextCentered(screen *ebiten.Image, posY int, text string) {
+	game.DrawText(screen, (screenWidth/characterWidth-textWidth(text))/2, posY, text)
+}
+
+// textWidth returns the number of columns text takes.
+func textWidth(text string) int {
+	return utf8.RuneCountInString(strings.ToUpper(text))
+}
@@ -1,73 +1,80 @@
 package game
 
 import (
-	"embed"
-	"fmt"
+	"context"
 	"image/color"
+	"io/fs"
+	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
 	"github.com/pkg/errors"
 )
 
 type Game struct {
-	fontImage    *ebiten.Image
-	playerImage  *ebiten.Image
-	tileSetImage *ebiten.Image
+	fontImage *ebiten.Image
 
 	fontCache map[int32]*ebiten.Image
 
+	input      InputSource
+	scene      Scene
+	state      *sokoban.State
 	player     *Player
 	boxes      []*Box
 	objects    []*object
 	sprites    map[SpriteName]*Sprite
-	stages     []Stage
+	packs      []level.Pack
+	stages     []level.Stage
 	stageIndex int
+	zoom       float64
+	progress   Progress
+	keepSave   bool
 
-	shouldDraw bool
-}
+	hint       chan hintResult
+	hintCancel context.CancelFunc
+	script     []Action
+	message    string
 
-func (game *Game) Update() error {
-	done := true
+	deadlockAt int
 
-	for i := range game.boxes {
-		game.boxes[i].Update(game)
-
-		if !game.boxes[i].Done(game) {
-			done = false
-		}
-	}
+	replay    *replayPlayer
+	ticks     int
+	moveTicks []int
+	restarts  []restartPoint
 
-	if game.player != nil {
-		game.player.Update(game)
-	}
+	shouldDraw bool
+}
 
-	if done {
-		game.nextStage()
+func (game *Game) Update() error {
+	err := game.scene.Update(game, game.input.Actions())
+	if err != nil {
+		return errors.Wrap(err, "error on update scene")
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyPageUp) {
-		game.nextStage()
-	}
+	return nil
+}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyPageDown) {
-		game.prevStage()
-	}
+// StageXSB returns the current stage with its current box and player positions in XSB notation.
+func (game *Game) StageXSB() string {
+	return game.state.XSB() + "Title: " + game.stages[game.stageIndex].DisplayName() + "\n"
+}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
-		game.startStage()
-	}
+// SolutionLURD returns the moves made on the current stage in LURD notation.
+func (game *Game) SolutionLURD() string {
+	return game.state.LURD()
+}
 
-	return nil
+func (game *Game) setMessage(message string) {
+	game.message = message
+	game.shouldDraw = true
 }
 
-const (
-	stepX  = 2
-	stepY  = 26
-	stageX = 22
-	stageY = 26
-)
+// SetInput replaces the source of player actions, which is keyboard by default.
+func (game *Game) SetInput(input InputSource) {
+	game.input = input
+}
 
 func (game *Game) Draw(screen *ebiten.Image) {
 	if !game.shouldDraw {
@@ -76,45 +83,43 @@ func (game *Game) Draw(screen *ebiten.Image) {
 		return
 	}
 
-	for i := range game.objects {
-		game.objects[i].Draw(game, screen)
-	}
-
-	for i := range game.boxes {
-		game.boxes[i].Draw(game, screen)
-	}
-
-	steps := 0
-
-	if game.player != nil {
-		game.player.Draw(game, screen)
-		steps = len(game.player.history)
-	}
-
-	// HUD
-	game.DrawText(screen, stepX, stepY, fmt.Sprintf("STEP %d", steps))
-	game.DrawText(screen, stageX, stageY, fmt.Sprintf("STAGE %s", game.stages[game.stageIndex].Name))
+	game.scene.Draw(game, screen)
 
 	game.shouldDraw = false
 }
 
-func (game *Game) Layout(int, int) (int, int) {
-	return screenWidth * scaleFactor, screenHeight * scaleFactor
+func (game *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return game.scene.Layout(outsideWidth, outsideHeight)
 }
 
-func New(assets embed.FS) (*Game, error) {
+// New creates the game with assets and its built-in pack from assets, followed by extra packs.
+func New(assets fs.FS, packs ...level.Pack) (*Game, error) {
 	game := Game{
-		fontImage:    nil,
-		playerImage:  nil,
-		tileSetImage: nil,
-		fontCache:    make(map[int32]*ebiten.Image),
-		player:       nil,
-		boxes:        nil,
-		objects:      nil,
-		sprites:      nil,
-		stages:       nil,
-		stageIndex:   0,
-		shouldDraw:   false,
+		fontImage:  nil,
+		fontCache:  make(map[int32]*ebiten.Image),
+		input:      NewKeyboardInput(),
+		scene:      newTitleScene(),
+		state:      nil,
+		player:     nil,
+		boxes:      nil,
+		objects:    nil,
+		sprites:    nil,
+		packs:      nil,
+		stages:     nil,
+		stageIndex: 0,
+		zoom:       scaleFactor,
+		progress:   newProgress(),
+		keepSave:   false,
+		hint:       nil,
+		hintCancel: nil,
+		script:     nil,
+		message:    "",
+		deadlockAt: -1,
+		replay:     nil,
+		ticks:      0,
+		moveTicks:  nil,
+		restarts:   nil,
+		shouldDraw: false,
 	}
 
 	err := game.loadImages(assets)
@@ -122,13 +127,29 @@ func New(assets embed.FS) (*Game, error) {
 		return nil, errors.Wrap(err, "error on load Images")
 	}
 
-	game.loadSprites()
+	err = game.loadSprites(assets, "assets/sprites.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "error on load sprites")
+	}
+
+	builtin, err := level.LoadPack(assets, "assets/stages")
+	if err != nil {
+		return nil, errors.Wrap(err, "error on load built-in pack")
+	}
 
-	err = game.loadStages(assets, "assets/stages")
+	err = game.addPacks(append([]level.Pack{builtin}, packs...))
 	if err != nil {
-		return nil, errors.Wrap(err, "error on load stages")
+		return nil, errors.Wrap(err, "error on add packs")
+	}
+
+	game.progress, err = loadProgress()
+	if err != nil {
+		log.Printf("error on load progress, progress won't be saved: %v", err)
+
+		game.keepSave = true
 	}
 
+	game.restoreStage()
 	game.startStage()
 
 	ebiten.SetWindowResizable(true)
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
+	"github.com/pkg/errors"
+)
+
+const hintTimeout = 5 * time.Second
+
+type hintResult struct {
+	steps []sokoban.Direction
+	err   error
+}
+
+func moveAction(dir sokoban.Direction) Action {
+	switch dir {
+	case sokoban.Left:
+		return ActionMoveLeft
+	case sokoban.Right:
+		return ActionMoveRight
+	case sokoban.Up:
+		return ActionMoveUp
+	case sokoban.Down:
+		return ActionMoveDown
+	default:
+		return ActionMoveUp
+	}
+}
+
+// startHint solves a copy of current state in background.
+func (game *Game) startHint() {
+	if game.hint != nil || len(game.script) > 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hintTimeout)
+	result := make(chan hintResult, 1)
+
+	go func(state *sokoban.State) {
+		defer cancel()
+
+		steps, err := sokoban.Solve(ctx, state)
+		result <- hintResult{steps: steps, err: err}
+	}(game.state.Clone())
+
+	game.hint = result
+	game.hintCancel = cancel
+	game.setMessage("THINKING")
+}
+
+// cancelHint drops a running search as its result won't match the state anymore.
+func (game *Game) cancelHint() {
+	if game.message != "" {
+		game.setMessage("")
+	}
+
+	if game.hint == nil {
+		return
+	}
+
+	game.hintCancel()
+
+	game.hint = nil
+	game.hintCancel = nil
+}
+
+func (game *Game) stopHint() {
+	game.cancelHint()
+
+	game.script = nil
+}
+
+// checkHint queues the steps up to the next push once the solver is done.
+func (game *Game) checkHint() {
+	if game.hint == nil {
+		return
+	}
+
+	var res hintResult
+
+	select {
+	case res = <-game.hint:
+	default:
+		return
+	}
+
+	game.hint = nil
+	game.hintCancel = nil
+
+	switch {
+	case errors.Is(res.err, sokoban.ErrUnsolvable):
+		game.setMessage("NO SOLUTION FROM HERE")
+
+		return
+	case res.err != nil:
+		// the hardest stages need longer than hintTimeout from their start
+		game.setMessage("NO HINT IN TIME")
+
+		return
+	}
+
+	game.setMessage("")
+
+	state := game.state.Clone()
+
+	game.script = make([]Action, 0)
+
+	for _, dir := range res.steps {
+		game.script = append(game.script, moveAction(dir))
+
+		if state.Move(dir) == sokoban.Pushed {
+			break
+		}
+	}
+}
+
+// rewind undoes the last steps one by one, animated as if undo was held.
+func (game *Game) rewind(steps int) {
+	if steps <= 0 || len(game.script) > 0 {
+		return
+	}
+
+	game.script = make([]Action, steps)
+
+	for i := range game.script {
+		game.script[i] = ActionUndo
+	}
+}
+
+// scriptActions replaces player movements with the queued steps while there is any.
+func (game *Game) scriptActions(actions []Action) []Action {
+	if len(game.script) == 0 {
+		return actions
+	}
+
+	res := make([]Action, 0, len(actions)+1)
+
+	for _, action := range actions {
+		if !action.controlsPlayer() {
+			res = append(res, action)
+		}
+	}
+
+	if game.player != nil && game.player.idle {
+		res = append(res, game.script[0])
+		game.script = game.script[1:]
+	}
+
+	return res
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type Action int
+
+const (
+	ActionMoveLeft Action = iota
+	ActionMoveRight
+	ActionMoveUp
+	ActionMoveDown
+	ActionUndo
+	ActionRedo
+	ActionUndoPush
+	ActionUndoWalk
+	ActionRestart
+	ActionNextStage
+	ActionPrevStage
+	ActionExport
+	ActionHint
+	ActionUndoDeadlock
+	ActionReplay
+	ActionReplayFaster
+	ActionReplaySlower
+	ActionReplayPause
+	ActionReplayStep
+	ActionMenuUp
+	ActionMenuDown
+	ActionMenuLeft
+	ActionMenuRight
+	ActionConfirm
+	ActionBack
+)
+
+// controlsPlayer tells whether action moves the player, either directly or through hint and replays.
+func (action Action) controlsPlayer() bool {
+	switch action {
+	case ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown, ActionUndo, ActionRedo, ActionUndoPush,
+		ActionUndoWalk, ActionHint, ActionUndoDeadlock, ActionReplay:
+		return true
+	default:
+		return false
+	}
+}
+
+func (action Action) controlsReplay() bool {
+	switch action {
+	case ActionReplayFaster, ActionReplaySlower, ActionReplayPause, ActionReplayStep:
+		return true
+	default:
+		return false
+	}
+}
+
+// InputSource yields the actions requested on the current frame.
+// Game calls Actions exactly once per Update.
+type InputSource interface {
+	Actions() []Action
+}
+
+// KeyboardInput repeats movements and undo while their keys are held,
+// other actions including menu navigation fire once per key press.
+type KeyboardInput struct{}
+
+func NewKeyboardInput() *KeyboardInput {
+	return &KeyboardInput{}
+}
+
+func (*KeyboardInput) Actions() []Action {
+	res := make([]Action, 0)
+
+	held := []struct {
+		key    ebiten.Key
+		action Action
+	}{
+		{key: ebiten.KeyLeft, action: ActionMoveLeft},
+		{key: ebiten.KeyRight, action: ActionMoveRight},
+		{key: ebiten.KeyUp, action: ActionMoveUp},
+		{key: ebiten.KeyDown, action: ActionMoveDown},
+		{key: ebiten.KeyBackspace, action: ActionUndo},
+		{key: ebiten.KeyY, action: ActionRedo},
+	}
+
+	for i := range held {
+		if ebiten.IsKeyPressed(held[i].key) {
+			res = append(res, held[i].action)
+		}
+	}
+
+	pressed := []struct {
+		key    ebiten.Key
+		action Action
+	}{
+		{key: ebiten.KeyPageUp, action: ActionNextStage},
+		{key: ebiten.KeyPageDown, action: ActionPrevStage},
+		{key: ebiten.KeyF5, action: ActionRestart},
+		{key: ebiten.KeyF9, action: ActionExport},
+		{key: ebiten.KeyH, action: ActionHint},
+		{key: ebiten.KeyU, action: ActionUndoDeadlock},
+		{key: ebiten.KeyZ, action: ActionUndoPush},
+		{key: ebiten.KeyX, action: ActionUndoWalk},
+		{key: ebiten.KeyR, action: ActionReplay},
+		{key: ebiten.KeyEscape, action: ActionBack},
+		{key: ebiten.KeyEnter, action: ActionConfirm},
+		{key: ebiten.KeyUp, action: ActionMenuUp},
+		{key: ebiten.KeyDown, action: ActionMenuDown},
+		{key: ebiten.KeyLeft, action: ActionMenuLeft},
+		{key: ebiten.KeyRight, action: ActionMenuRight},
+		{key: ebiten.KeyEqual, action: ActionReplayFaster},
+		{key: ebiten.KeyMinus, action: ActionReplaySlower},
+		{key: ebiten.KeySpace, action: ActionReplayPause},
+		{key: ebiten.KeyPeriod, action: ActionReplayStep},
+	}
+
+	for i := range pressed {
+		if inpututil.IsKeyJustPressed(pressed[i].key) {
+			res = append(res, pressed[i].action)
+		}
+	}
+
+	return res
+}
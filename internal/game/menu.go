@@ -0,0 +1,77 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const menuCursor = "> "
+
+// menu is a vertical list of options picked with up, down and confirm.
+type menu struct {
+	items    []string
+	selected int
+}
+
+func newMenu(items ...string) *menu {
+	return &menu{
+		items:    items,
+		selected: 0,
+	}
+}
+
+// Update moves the cursor and returns the confirmed item or -1.
+func (m *menu) Update(game *Game, actions []Action) int {
+	for _, action := range actions {
+		switch action {
+		case ActionMenuUp:
+			m.selected = (m.selected + len(m.items) - 1) % len(m.items)
+			game.shouldDraw = true
+		case ActionMenuDown:
+			m.selected = (m.selected + 1) % len(m.items)
+			game.shouldDraw = true
+		case ActionConfirm:
+			return m.selected
+		default:
+		}
+	}
+
+	return -1
+}
+
+func (m *menu) Width() int {
+	width := 0
+
+	for i := range m.items {
+		if len(m.items[i]) > width {
+			width = len(m.items[i])
+		}
+	}
+
+	return width + len(menuCursor)
+}
+
+// Draw renders the menu centered horizontally with its first item on row posY, over a black panel.
+func (m *menu) Draw(game *Game, screen *ebiten.Image, posY int) {
+	posX := (screenWidth/characterWidth - m.Width()) / 2
+
+	drawPanel(screen, posX-1, posY-1, m.Width()+2, len(m.items)+2)
+
+	for i := range m.items {
+		cursor := "  "
+		if i == m.selected {
+			cursor = menuCursor
+		}
+
+		game.DrawText(screen, posX, posY+i, cursor+m.items[i])
+	}
+}
+
+// drawPanel fills a black rectangle in text cells.
+func drawPanel(screen *ebiten.Image, posX, posY, width, height int) {
+	const cell = characterWidth * scaleFactor
+
+	ebitenutil.DrawRect(screen, float64(posX*cell), float64(posY*cell), float64(width*cell), float64(height*cell), color.Black)
+}
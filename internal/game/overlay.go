@@ -0,0 +1,184 @@
+package game
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Overlay is a read only file system of layers, where files of upper layers hide the same files
+// of lower ones. Directories list the files of every layer.
+type Overlay struct {
+	layers []overlayLayer
+}
+
+// overlayLayer is a file system mounted at dir.
+type overlayLayer struct {
+	fsys fs.FS
+	dir  string
+}
+
+// NewOverlay creates an overlay with base as its lowest layer.
+func NewOverlay(base fs.FS) *Overlay {
+	return &Overlay{
+		layers: []overlayLayer{{fsys: base, dir: "."}},
+	}
+}
+
+// Mount adds fsys on top of the overlay, so its files are seen under dir.
+func (overlay *Overlay) Mount(dir string, fsys fs.FS) {
+	overlay.layers = append(overlay.layers, overlayLayer{fsys: fsys, dir: path.Clean(dir)})
+}
+
+// resolve returns the name of a file in the layer, and whether the layer has it in its mount.
+func (layer overlayLayer) resolve(name string) (string, bool) {
+	switch {
+	case layer.dir == ".":
+		return name, true
+	case name == layer.dir:
+		return ".", true
+	case strings.HasPrefix(name, layer.dir+"/"):
+		return strings.TrimPrefix(name, layer.dir+"/"), true
+	default:
+		return "", false
+	}
+}
+
+func (overlay *Overlay) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for i := len(overlay.layers) - 1; i >= 0; i-- {
+		rel, ok := overlay.layers[i].resolve(name)
+		if !ok {
+			continue
+		}
+
+		file, err := overlay.layers[i].fsys.Open(rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error on open file")
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+
+			return nil, errors.Wrap(err, "error on stat file")
+		}
+
+		if !info.IsDir() {
+			return file, nil
+		}
+
+		entries, err := overlay.ReadDir(name)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, errors.Wrap(err, "error on read directory")
+		}
+
+		return &overlayDir{File: file, info: info, name: path.Base(name), entries: entries}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists a directory of every layer, sorted by name, upper layers winning on the same name.
+func (overlay *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries := make(map[string]fs.DirEntry)
+	found := false
+
+	for i := len(overlay.layers) - 1; i >= 0; i-- {
+		rel, ok := overlay.layers[i].resolve(name)
+		if !ok {
+			continue
+		}
+
+		list, err := fs.ReadDir(overlay.layers[i].fsys, rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error on read directory")
+		}
+
+		found = true
+
+		for _, entry := range list {
+			if _, ok := entries[entry.Name()]; !ok {
+				entries[entry.Name()] = entry
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	res := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name() < res[j].Name() })
+
+	return res, nil
+}
+
+// overlayDir is an open directory of the overlay, listing the files of every layer.
+type overlayDir struct {
+	fs.File
+	info    fs.FileInfo
+	name    string
+	entries []fs.DirEntry
+}
+
+func (dir *overlayDir) Stat() (fs.FileInfo, error) {
+	return overlayDirInfo{FileInfo: dir.info, name: dir.name}, nil
+}
+
+func (dir *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		res := dir.entries
+		dir.entries = nil
+
+		return res, nil
+	}
+
+	if len(dir.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(dir.entries) {
+		n = len(dir.entries)
+	}
+
+	res := dir.entries[:n]
+	dir.entries = dir.entries[n:]
+
+	return res, nil
+}
+
+// overlayDirInfo names a directory by its path in the overlay, not in the layer it's mounted from.
+type overlayDirInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (info overlayDirInfo) Name() string {
+	return info.name
+}
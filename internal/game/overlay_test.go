@@ -0,0 +1,100 @@
+package game
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+)
+
+func testOverlay() *Overlay {
+	overlay := NewOverlay(fstest.MapFS{
+		"assets/font.png":         &fstest.MapFile{Data: []byte("base font")},
+		"assets/player.png":       &fstest.MapFile{Data: []byte("base player")},
+		"assets/stages/1.tmx":     &fstest.MapFile{Data: []byte("base stage")},
+		"assets/stages/tiles.tsx": &fstest.MapFile{Data: []byte("base tiles")},
+		"wasm_exec.js":            &fstest.MapFile{Data: []byte("base script")},
+	})
+
+	overlay.Mount("assets", fstest.MapFS{
+		"player.png":   &fstest.MapFile{Data: []byte("mod player")},
+		"stages/2.tmx": &fstest.MapFile{Data: []byte("mod stage")},
+	})
+
+	return overlay
+}
+
+func TestOverlayOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+		err  error
+	}{
+		{name: "base file", file: "assets/font.png", want: "base font", err: nil},
+		{name: "mod wins", file: "assets/player.png", want: "mod player", err: nil},
+		{name: "mod adds", file: "assets/stages/2.tmx", want: "mod stage", err: nil},
+		{name: "outside mount", file: "wasm_exec.js", want: "base script", err: nil},
+		{name: "not mounted at root", file: "player.png", want: "", err: fs.ErrNotExist},
+		{name: "missing", file: "assets/tileset.png", want: "", err: fs.ErrNotExist},
+		{name: "invalid path", file: "assets/../wasm_exec.js", want: "", err: fs.ErrInvalid},
+	}
+
+	overlay := testOverlay()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := fs.ReadFile(overlay, tt.file)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadFile() error = %v, want %v", err, tt.err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("ReadFile() = %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlayReadDir(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+		err  error
+	}{
+		{name: "root", dir: ".", want: []string{"assets", "wasm_exec.js"}, err: nil},
+		{name: "mount", dir: "assets", want: []string{"font.png", "player.png", "stages"}, err: nil},
+		{name: "both layers", dir: "assets/stages", want: []string{"1.tmx", "2.tmx", "tiles.tsx"}, err: nil},
+		{name: "missing", dir: "assets/fonts", want: nil, err: fs.ErrNotExist},
+	}
+
+	overlay := testOverlay()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := fs.ReadDir(overlay, tt.dir)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadDir() error = %v, want %v", err, tt.err)
+			}
+
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ReadDir() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlayFS(t *testing.T) {
+	err := fstest.TestFS(testOverlay(), "assets/font.png", "assets/player.png", "assets/stages/1.tmx", "assets/stages/2.tmx", "wasm_exec.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const pauseMenuY = 11
+
+const (
+	pauseResume = iota
+	pauseRestart
+	pauseStageSelect
+	pauseTitle
+)
+
+// pauseScene freezes play and shows its menu over the stage.
+type pauseScene struct {
+	play *playScene
+	menu *menu
+}
+
+func newPauseScene(play *playScene) *pauseScene {
+	return &pauseScene{
+		play: play,
+		menu: newMenu("RESUME", "RESTART", "STAGE SELECT", "TITLE"),
+	}
+}
+
+func (scene *pauseScene) Update(game *Game, actions []Action) error {
+	for _, action := range actions {
+		if action == ActionBack {
+			game.SetScene(scene.play)
+
+			return nil
+		}
+	}
+
+	switch scene.menu.Update(game, actions) {
+	case pauseResume:
+		game.SetScene(scene.play)
+	case pauseRestart:
+		game.SetScene(scene.play)
+		game.requestRestart(scene.play)
+	case pauseStageSelect:
+		game.SetScene(newStageSelectScene(game))
+	case pauseTitle:
+		game.SetScene(newTitleScene())
+	}
+
+	return nil
+}
+
+func (scene *pauseScene) Draw(game *Game, screen *ebiten.Image) {
+	scene.play.Draw(game, screen)
+	scene.menu.Draw(game, screen, pauseMenuY)
+}
+
+func (*pauseScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
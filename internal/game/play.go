@@ -0,0 +1,156 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	messageX = 2
+	messageY = 1
+	replayX  = 28
+	replayY  = 1
+	hudLeftX = 1
+	hudMidX  = 12
+	hudTopY  = 26
+	hudLowY  = 27
+	// hudStageX is the leftmost column stage label and difficulty may use, they're right aligned to the screen edge.
+	hudStageX = 24
+)
+
+// playScene runs current stage.
+type playScene struct{}
+
+func newPlayScene() *playScene {
+	return &playScene{}
+}
+
+func (scene *playScene) Update(game *Game, actions []Action) error {
+	game.checkHint()
+
+	game.ticks++
+
+	if game.ticks%fps == 0 {
+		game.shouldDraw = true
+	}
+
+	actions = game.scriptActions(game.replayActions(actions))
+
+	done := game.state.IsSolved()
+
+	for i := range game.boxes {
+		game.boxes[i].Update(game)
+
+		if done && !game.boxes[i].Done(game) {
+			done = false
+		}
+	}
+
+	if game.player != nil {
+		game.player.Update(game, actions)
+	}
+
+	if done {
+		res := game.completeStage()
+
+		game.replay = nil
+		game.SetScene(newCompleteScene(res))
+
+		return nil
+	}
+
+	for _, action := range actions {
+		switch action {
+		case ActionNextStage:
+			game.nextStage()
+		case ActionPrevStage:
+			game.prevStage()
+		case ActionRestart:
+			if game.replay != nil {
+				game.startStage()
+
+				continue
+			}
+
+			game.requestRestart(scene)
+		case ActionExport:
+			game.export()
+		case ActionHint:
+			game.startHint()
+		case ActionUndoDeadlock:
+			game.undoDeadlock()
+		case ActionUndoPush:
+			game.rewind(game.state.StepsToLastPush())
+		case ActionUndoWalk:
+			game.rewind(game.state.WalksSinceLastPush())
+		case ActionReplay:
+			game.startReplay()
+		case ActionBack:
+			if game.replay != nil {
+				game.replay = nil
+				game.shouldDraw = true
+
+				continue
+			}
+
+			game.SetScene(newPauseScene(scene))
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (*playScene) Draw(game *Game, screen *ebiten.Image) {
+	clearScreen(screen)
+
+	for i := range game.objects {
+		game.objects[i].Draw(game, screen)
+	}
+
+	for i := range game.boxes {
+		game.boxes[i].Draw(game, screen)
+	}
+
+	if game.player != nil {
+		game.player.Draw(game, screen)
+	}
+
+	drawHUD(game, screen)
+
+	if game.message != "" {
+		game.DrawText(screen, messageX, messageY, game.message)
+	}
+
+	if game.replay != nil {
+		game.DrawText(screen, replayX, replayY, game.replay.Status())
+	}
+}
+
+// drawHUD renders scores and stage label on the two bottom rows.
+func drawHUD(game *Game, screen *ebiten.Image) {
+	stg := game.stages[game.stageIndex]
+	stage := fitText(stg.Label(), screenWidth/characterWidth-hudStageX)
+	difficulty := fitText(stg.Difficulty, screenWidth/characterWidth-hudStageX)
+
+	game.DrawText(screen, hudLeftX, hudTopY, fmt.Sprintf("MOVES %d", game.state.Moves()))
+	game.DrawText(screen, hudMidX, hudTopY, fmt.Sprintf("PUSHES %d", game.state.Pushes()))
+	game.DrawText(screen, screenWidth/characterWidth-textWidth(stage), hudTopY, stage)
+	game.DrawText(screen, hudLeftX, hudLowY, fmt.Sprintf("BOXES %d/%d", game.state.BoxesOnGoal(), len(game.boxes)))
+	game.DrawText(screen, hudMidX, hudLowY, "TIME "+formatTicks(game.ticks))
+	game.DrawText(screen, screenWidth/characterWidth-textWidth(difficulty), hudLowY, difficulty)
+}
+
+// fitText cuts text to at most maxLen characters.
+func fitText(text string, maxLen int) string {
+	if runes := []rune(text); len(runes) > maxLen {
+		return string(runes[:maxLen])
+	}
+
+	return text
+}
+
+func (*playScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
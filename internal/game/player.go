@@ -1,9 +1,10 @@
 package game
 
 import (
-	"math"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
 )
 
 type Player struct {
@@ -14,17 +15,8 @@ type Player struct {
 	currentSprite        SpriteName
 	idle                 bool
 	pushing              bool
-	history              []int
-	boxHistory           []*Box
 }
 
-const (
-	moveLeft = iota
-	moveRight
-	moveUp
-	moveDown
-)
-
 const fps = 60.0
 
 func (p Player) DesiredX() float64 {
@@ -42,271 +34,92 @@ func (p *Player) SetCurrentSprite(sprite SpriteName) {
 	}
 }
 
-func (p *Player) IsWallAtLeft(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(p.I-1, p.J)
-}
-
-func (p *Player) IsWallAtRight(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(p.I+1, p.J)
-}
-
-func (p *Player) IsWallAtTop(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(p.I, p.J-1)
-}
-
-func (p *Player) IsWallAtBottom(game *Game) bool {
-	return game.stages[game.stageIndex].IsWall(p.I, p.J+1)
-}
-
-func (p *Player) BoxAtLeft(game *Game) *Box {
-	for i := range game.boxes {
-		if game.boxes[i].I == p.I-1 && game.boxes[i].J == p.J {
-			return game.boxes[i]
-		}
-	}
-
-	return nil
-}
-
-func (p *Player) BoxAtRight(game *Game) *Box {
-	for i := range game.boxes {
-		if game.boxes[i].I == p.I+1 && game.boxes[i].J == p.J {
-			return game.boxes[i]
-		}
-	}
-
-	return nil
-}
-
-func (p *Player) BoxAtTop(game *Game) *Box {
-	for i := range game.boxes {
-		if game.boxes[i].I == p.I && game.boxes[i].J == p.J-1 {
-			return game.boxes[i]
-		}
-	}
-
-	return nil
-}
-
-func (p *Player) BoxAtBottom(game *Game) *Box {
-	for i := range game.boxes {
-		if game.boxes[i].I == p.I && game.boxes[i].J == p.J+1 {
-			return game.boxes[i]
-		}
-	}
-
-	return nil
-}
-
-func (p *Player) checkLeft(game *Game) {
-	if !p.idle || !ebiten.IsKeyPressed(ebiten.KeyLeft) || p.IsWallAtLeft(game) {
+func (p *Player) move(game *Game, dir sokoban.Direction) {
+	result := game.state.Move(dir)
+	if result == sokoban.Blocked {
 		return
 	}
 
-	pushing := false
+	game.moveTicks = append(game.moveTicks, game.ticks)
 
-	if box := p.BoxAtLeft(game); box != nil {
-		if box.IsWallAtLeft(game) {
-			return
-		}
+	step, _ := game.state.LastStep()
 
-		if box.IsBoxAtLeft(game) {
-			return
-		}
-
-		pushing = true
-
-		box.I--
-
-		p.boxHistory = append(p.boxHistory, box)
-	}
-
-	p.I--
-	p.direction = directionLeft
-	p.idle = false
-	p.pushing = pushing
-	p.history = append(p.history, moveLeft)
-
-	if !pushing {
-		p.boxHistory = append(p.boxHistory, nil)
-	}
+	p.start(game, step)
 }
 
-func (p *Player) checkRight(game *Game) {
-	if !p.idle || !ebiten.IsKeyPressed(ebiten.KeyRight) || p.IsWallAtRight(game) {
+func (p *Player) undo(game *Game) {
+	if game.undoRestart() {
 		return
 	}
 
-	pushing := false
-
-	if box := p.BoxAtRight(game); box != nil {
-		if box.IsWallAtRight(game) {
-			return
-		}
-
-		if box.IsBoxAtRight(game) {
-			return
-		}
-
-		pushing = true
-
-		box.I++
-
-		p.boxHistory = append(p.boxHistory, box)
-	}
-
-	p.I++
-	p.direction = directionRight
-	p.idle = false
-	p.pushing = pushing
-	p.history = append(p.history, moveRight)
-
-	if !pushing {
-		p.boxHistory = append(p.boxHistory, nil)
-	}
-}
-
-func (p *Player) checkUp(game *Game) {
-	if !p.idle || !ebiten.IsKeyPressed(ebiten.KeyUp) || p.IsWallAtTop(game) {
+	step, ok := game.state.Undo()
+	if !ok {
 		return
 	}
 
-	pushing := false
-
-	if box := p.BoxAtTop(game); box != nil {
-		if box.IsWallAtTop(game) {
-			return
-		}
+	game.moveTicks = game.moveTicks[:len(game.moveTicks)-1]
 
-		if box.IsBoxAtTop(game) {
-			return
-		}
-
-		pushing = true
-
-		box.J--
-
-		p.boxHistory = append(p.boxHistory, box)
-	}
-
-	p.J--
-	p.direction = directionUp
-	p.idle = false
-	p.pushing = pushing
-	p.history = append(p.history, moveUp)
-
-	if !pushing {
-		p.boxHistory = append(p.boxHistory, nil)
-	}
+	p.start(game, step)
 }
 
-func (p *Player) checkDown(game *Game) {
-	if !p.idle || !ebiten.IsKeyPressed(ebiten.KeyDown) || p.IsWallAtBottom(game) {
+func (p *Player) redo(game *Game) {
+	step, ok := game.state.Redo()
+	if !ok {
 		return
 	}
 
-	pushing := false
-
-	if box := p.BoxAtBottom(game); box != nil {
-		if box.IsWallAtBottom(game) {
-			return
-		}
+	game.moveTicks = append(game.moveTicks, game.ticks)
 
-		if box.IsBoxAtBottom(game) {
-			return
-		}
-
-		pushing = true
-
-		box.J++
+	p.start(game, step)
+}
 
-		p.boxHistory = append(p.boxHistory, box)
-	}
+// start animates player and boxes towards their new cells in state.
+func (p *Player) start(game *Game, step sokoban.Step) {
+	game.cancelHint()
 
-	p.J++
-	p.direction = directionDown
+	p.direction = rotation(step.Direction)
 	p.idle = false
-	p.pushing = pushing
+	p.pushing = step.Pushed()
 
-	p.history = append(p.history, moveDown)
+	pos := game.state.Player()
+	p.I, p.J = pos.I, pos.J
 
-	if !pushing {
-		p.boxHistory = append(p.boxHistory, nil)
-	}
+	game.syncBox(step.Box)
+	game.checkDeadlock()
 }
 
-func (p *Player) checkUndo() {
-	if !p.idle || !ebiten.IsKeyPressed(ebiten.KeyBackspace) || len(p.history) == 0 {
-		return
-	}
-
-	pushing := false
-
-	switch p.history[len(p.history)-1] {
-	case moveLeft:
-		p.I++
-		p.direction = directionLeft
-
-		if p.boxHistory[len(p.history)-1] != nil {
-			pushing = true
-			p.boxHistory[len(p.history)-1].I++
-		}
-	case moveRight:
-		p.I--
-		p.direction = directionRight
-
-		if p.boxHistory[len(p.history)-1] != nil {
-			pushing = true
-			p.boxHistory[len(p.history)-1].I--
-		}
-	case moveUp:
-		p.J++
-		p.direction = directionUp
-
-		if p.boxHistory[len(p.history)-1] != nil {
-			pushing = true
-			p.boxHistory[len(p.history)-1].J++
+func (p *Player) checkActions(game *Game, actions []Action) {
+	for _, action := range actions {
+		if !p.idle {
+			return
 		}
-	case moveDown:
-		p.J--
-		p.direction = directionDown
 
-		if p.boxHistory[len(p.history)-1] != nil {
-			pushing = true
-			p.boxHistory[len(p.history)-1].J--
+		switch action {
+		case ActionMoveLeft:
+			p.move(game, sokoban.Left)
+		case ActionMoveRight:
+			p.move(game, sokoban.Right)
+		case ActionMoveUp:
+			p.move(game, sokoban.Up)
+		case ActionMoveDown:
+			p.move(game, sokoban.Down)
+		case ActionUndo:
+			p.undo(game)
+		case ActionRedo:
+			p.redo(game)
+		default:
 		}
 	}
-
-	p.idle = false
-	p.pushing = pushing
-
-	p.history = p.history[:len(p.history)-1]
-	p.boxHistory = p.boxHistory[:len(p.boxHistory)-1]
 }
 
-func (p *Player) Update(game *Game) {
-	p.checkLeft(game)
-	p.checkRight(game)
-	p.checkUp(game)
-	p.checkDown(game)
-	p.checkUndo()
-
-	if p.DesiredX() != p.PositionX {
-		if math.Signbit(p.DesiredX() - p.PositionX) {
-			p.PositionX -= movementSpeed
-		} else {
-			p.PositionX += movementSpeed
-		}
+func (p *Player) Update(game *Game, actions []Action) {
+	p.checkActions(game, actions)
 
-		game.shouldDraw = true
-	}
+	speed := movementSpeed * game.speed()
 
-	if p.DesiredY() != p.PositionY {
-		if math.Signbit(p.DesiredY() - p.PositionY) {
-			p.PositionY -= movementSpeed
-		} else {
-			p.PositionY += movementSpeed
-		}
+	if p.DesiredX() != p.PositionX || p.DesiredY() != p.PositionY {
+		p.PositionX = approach(p.PositionX, p.DesiredX(), speed)
+		p.PositionY = approach(p.PositionY, p.DesiredY(), speed)
 
 		game.shouldDraw = true
 	}
@@ -351,9 +164,7 @@ func (p *Player) Draw(game *Game, screen *ebiten.Image) {
 
 	p.animation += 1.0 / fps
 
-	currentFrameIndex := int(p.animation/(1/sprite.Speed)) % len(sprite.Images)
-
-	img := sprite.Images[currentFrameIndex]
+	img := sprite.ImageAt(time.Duration(p.animation * float64(time.Second)))
 
 	screen.DrawImage(img, &opts)
 }
@@ -0,0 +1,258 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
+	"github.com/pkg/errors"
+)
+
+const replayDir = "replays"
+
+var ErrReplayMismatch = errors.New("replay was recorded on another layout of the stage")
+
+// Replay is a completed run of a stage.
+// Ticks holds the frame each move started at, counted from stage start at fps.
+type Replay struct {
+	Stage    string `json:"stage"`
+	Level    string `json:"level"`
+	Moves    string `json:"moves"`
+	Ticks    []int  `json:"ticks"`
+	Duration int    `json:"duration"`
+}
+
+func replayName(stage string) string {
+	return path.Join(replayDir, keyFileName(stage)+".json")
+}
+
+// keyFileName turns a stage key, which may hold any character, into a file name valid on every system.
+func keyFileName(stage string) string {
+	sum := sha256.Sum256([]byte(stage))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func loadReplay(stage string) (*Replay, error) {
+	b, err := readData(replayName(stage))
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read replay")
+	}
+
+	if b == nil {
+		return nil, nil
+	}
+
+	var replay Replay
+
+	err = json.Unmarshal(b, &replay)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on unmarshal replay")
+	}
+
+	return &replay, nil
+}
+
+func (replay *Replay) save() error {
+	b, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error on marshal replay")
+	}
+
+	err = writeData(replayName(replay.Stage), b)
+	if err != nil {
+		return errors.Wrap(err, "error on write replay")
+	}
+
+	return nil
+}
+
+// saveReplay keeps the run just completed as the replay of current stage.
+func (game *Game) saveReplay() {
+	stage := game.stages[game.stageIndex]
+
+	replay := Replay{
+		Stage:    stage.Key(),
+		Level:    stage.XSB(),
+		Moves:    game.state.LURD(),
+		Ticks:    append([]int(nil), game.moveTicks...),
+		Duration: game.ticks,
+	}
+
+	if err := replay.save(); err != nil {
+		log.Printf("error on save replay: %v", err)
+	}
+}
+
+func replaySpeeds() []float64 {
+	return []float64{.5, 1, 2, 4, 8}
+}
+
+const defaultReplaySpeed = 1
+
+// replayPlayer feeds recorded moves to the player through the same actions the input does.
+type replayPlayer struct {
+	moves  []sokoban.Direction
+	ticks  []int
+	next   int
+	clock  float64
+	speed  int
+	paused bool
+	step   bool
+}
+
+// newReplayPlayer plays replay on stage, which must still have the layout it was recorded on.
+// The title may have changed since.
+func newReplayPlayer(replay *Replay, stage level.Stage) (*replayPlayer, error) {
+	if !strings.HasPrefix(replay.Level, stage.NewState().XSB()+"Title: ") {
+		return nil, ErrReplayMismatch
+	}
+
+	moves, err := sokoban.ParseLURD(replay.Moves)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on parse moves")
+	}
+
+	ticks := replay.Ticks
+	if len(ticks) != len(moves) {
+		ticks = make([]int, len(moves))
+	}
+
+	return &replayPlayer{
+		moves:  moves,
+		ticks:  ticks,
+		next:   0,
+		clock:  0,
+		speed:  defaultReplaySpeed,
+		paused: false,
+		step:   false,
+	}, nil
+}
+
+func (rp *replayPlayer) Speed() float64 {
+	return replaySpeeds()[rp.speed]
+}
+
+func (rp *replayPlayer) Done() bool {
+	return rp.next >= len(rp.moves)
+}
+
+func (rp *replayPlayer) control(action Action) {
+	switch action {
+	case ActionReplayFaster:
+		if rp.speed < len(replaySpeeds())-1 {
+			rp.speed++
+		}
+	case ActionReplaySlower:
+		if rp.speed > 0 {
+			rp.speed--
+		}
+	case ActionReplayPause:
+		rp.paused = !rp.paused
+	case ActionReplayStep:
+		rp.paused = true
+		rp.step = true
+	default:
+	}
+}
+
+// Actions advances replay clock and returns the next move once it is due and the player is free.
+func (rp *replayPlayer) Actions(idle bool) []Action {
+	if !rp.paused {
+		rp.clock += rp.Speed()
+	}
+
+	if rp.Done() || !idle {
+		return nil
+	}
+
+	due := !rp.paused && rp.clock >= float64(rp.ticks[rp.next])
+	if !due && !rp.step {
+		return nil
+	}
+
+	if rp.step {
+		rp.clock = float64(rp.ticks[rp.next])
+	}
+
+	rp.step = false
+	rp.next++
+
+	return []Action{moveAction(rp.moves[rp.next-1])}
+}
+
+func (rp *replayPlayer) Status() string {
+	if rp.paused {
+		return "PAUSED"
+	}
+
+	return fmt.Sprintf("REPLAY %gX", rp.Speed())
+}
+
+// startReplay restarts current stage and plays its last completed run.
+// It reports false and leaves the stage as is when there's nothing to play.
+func (game *Game) startReplay() bool {
+	stage := game.stages[game.stageIndex]
+
+	replay, err := loadReplay(stage.Key())
+	if err != nil {
+		log.Printf("error on load replay: %v", err)
+	}
+
+	if replay == nil {
+		game.setMessage("NO REPLAY")
+
+		return false
+	}
+
+	rp, err := newReplayPlayer(replay, stage)
+	if err != nil {
+		log.Printf("error on new replay player: %v", err)
+		game.setMessage("BAD REPLAY")
+
+		return false
+	}
+
+	game.startStage()
+	game.replay = rp
+
+	return true
+}
+
+// replayActions replaces player actions with the replayed ones while a replay is playing.
+func (game *Game) replayActions(actions []Action) []Action {
+	if game.replay == nil {
+		return actions
+	}
+
+	res := make([]Action, 0, len(actions)+1)
+
+	for _, action := range actions {
+		switch {
+		case action.controlsReplay():
+			game.replay.control(action)
+			game.shouldDraw = true
+		case !action.controlsPlayer():
+			res = append(res, action)
+		}
+	}
+
+	idle := game.player != nil && game.player.idle
+
+	return append(res, game.replay.Actions(idle)...)
+}
+
+// speed is the rate player and boxes move at.
+func (game *Game) speed() float64 {
+	if game.replay != nil {
+		return game.replay.Speed()
+	}
+
+	return 1
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// restartConfirmMoves is the history length from which restart asks first.
+const restartConfirmMoves = 100
+
+// restartPoint is the game side of a restart kept in state, so undo can bring it back.
+type restartPoint struct {
+	ticks      int
+	moveTicks  []int
+	deadlockAt int
+}
+
+// requestRestart restarts current stage, asking first when a long history is about to go.
+func (game *Game) requestRestart(play *playScene) {
+	if game.state.Moves() < restartConfirmMoves {
+		game.restart()
+
+		return
+	}
+
+	game.SetScene(newConfirmScene(play, "RESTART STAGE?", game.restart))
+}
+
+// restart takes player and boxes back to start in a way undo can revert.
+func (game *Game) restart() {
+	ticks, moveTicks, deadlockAt := game.ticks, game.moveTicks, game.deadlockAt
+
+	if !game.state.Restart() {
+		return
+	}
+
+	game.restarts = append(game.restarts, restartPoint{
+		ticks:      ticks,
+		moveTicks:  moveTicks,
+		deadlockAt: deadlockAt,
+	})
+
+	game.ticks = 0
+	game.moveTicks = make([]int, 0)
+	game.deadlockAt = -1
+
+	game.stopHint()
+	game.snapPositions()
+}
+
+// undoRestart returns to the position before the last restart.
+func (game *Game) undoRestart() bool {
+	if len(game.restarts) == 0 || !game.state.UndoRestart() {
+		return false
+	}
+
+	last := game.restarts[len(game.restarts)-1]
+	game.restarts = game.restarts[:len(game.restarts)-1]
+
+	game.ticks = last.ticks
+	game.moveTicks = last.moveTicks
+	game.deadlockAt = last.deadlockAt
+
+	game.stopHint()
+	game.snapPositions()
+
+	return true
+}
+
+// snapPositions puts player and boxes right on their cells in state without animation.
+func (game *Game) snapPositions() {
+	game.syncPositions()
+
+	if game.player != nil {
+		game.player.PositionX, game.player.PositionY = game.player.DesiredX(), game.player.DesiredY()
+		game.player.idle = true
+		game.player.pushing = false
+	}
+
+	for i := range game.boxes {
+		game.boxes[i].PositionX, game.boxes[i].PositionY = game.boxes[i].DesiredX(), game.boxes[i].DesiredY()
+	}
+
+	game.checkDeadlock()
+
+	game.shouldDraw = true
+}
+
+// confirmScene asks a yes or no question over the stage.
+type confirmScene struct {
+	play     *playScene
+	question string
+	yes      func()
+	menu     *menu
+}
+
+const (
+	confirmQuestionY = 10
+	confirmMenuY     = 13
+)
+
+const (
+	confirmNo = iota
+	confirmYes
+)
+
+func newConfirmScene(play *playScene, question string, yes func()) *confirmScene {
+	return &confirmScene{
+		play:     play,
+		question: question,
+		yes:      yes,
+		menu:     newMenu("NO", "YES"),
+	}
+}
+
+func (scene *confirmScene) Update(game *Game, actions []Action) error {
+	for _, action := range actions {
+		if action == ActionBack {
+			game.SetScene(scene.play)
+
+			return nil
+		}
+	}
+
+	switch scene.menu.Update(game, actions) {
+	case confirmNo:
+		game.SetScene(scene.play)
+	case confirmYes:
+		scene.yes()
+		game.SetScene(scene.play)
+	}
+
+	return nil
+}
+
+func (scene *confirmScene) Draw(game *Game, screen *ebiten.Image) {
+	scene.play.Draw(game, screen)
+
+	posX := (screenWidth/characterWidth - len(scene.question)) / 2
+
+	drawPanel(screen, posX-1, confirmQuestionY-1, len(scene.question)+2, 3)
+	game.DrawText(screen, posX, confirmQuestionY, scene.question)
+	scene.menu.Draw(game, screen, confirmMenuY)
+}
+
+func (*confirmScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
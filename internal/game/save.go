@@ -0,0 +1,153 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	saveVersion = 1
+	saveName    = "save.json"
+)
+
+var ErrUnknownSaveVersion = errors.New("save is from another version of the game")
+
+type StageRecord struct {
+	Completed  bool `json:"completed"`
+	BestMoves  int  `json:"bestMoves"`
+	BestPushes int  `json:"bestPushes"`
+}
+
+// Progress is what is kept between sessions. Stages are keyed by name.
+type Progress struct {
+	Version   int                    `json:"version"`
+	LastStage string                 `json:"lastStage"`
+	Stages    map[string]StageRecord `json:"stages"`
+}
+
+func newProgress() Progress {
+	return Progress{
+		Version:   saveVersion,
+		LastStage: "",
+		Stages:    make(map[string]StageRecord),
+	}
+}
+
+// loadProgress reads the saved progress, or returns new progress when nothing is saved yet.
+// A save that can't be read or is of another version is an error, and must then be kept as it is.
+func loadProgress() (Progress, error) {
+	progress := newProgress()
+
+	b, err := readData(saveName)
+	if err != nil {
+		return progress, errors.Wrap(err, "error on read save")
+	}
+
+	if len(b) == 0 {
+		return progress, nil
+	}
+
+	err = json.Unmarshal(b, &progress)
+	if err != nil {
+		return newProgress(), errors.Wrap(err, "error on unmarshal save")
+	}
+
+	if progress.Version != saveVersion {
+		return newProgress(), errors.Wrapf(ErrUnknownSaveVersion, "version %d", progress.Version)
+	}
+
+	if progress.Stages == nil {
+		progress.Stages = make(map[string]StageRecord)
+	}
+
+	return progress, nil
+}
+
+func (progress Progress) save() error {
+	b, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error on marshal save")
+	}
+
+	err = writeData(saveName, b)
+	if err != nil {
+		return errors.Wrap(err, "error on write save")
+	}
+
+	return nil
+}
+
+// record keeps moves and pushes of a completed stage if they're better than the last ones.
+func (progress Progress) record(name string, moves, pushes int) {
+	rec := progress.Stages[name]
+
+	if !rec.Completed || moves < rec.BestMoves {
+		rec.BestMoves = moves
+	}
+
+	if !rec.Completed || pushes < rec.BestPushes {
+		rec.BestPushes = pushes
+	}
+
+	rec.Completed = true
+	progress.Stages[name] = rec
+}
+
+// saveProgress persists progress. A failing save mustn't stop the game, so it's only logged.
+// Nothing is saved when the save couldn't be loaded, so it isn't overwritten with empty progress.
+func (game *Game) saveProgress() {
+	if game.keepSave {
+		return
+	}
+
+	if err := game.progress.save(); err != nil {
+		log.Printf("error on save progress: %v", err)
+	}
+}
+
+// completeStage records the run just completed and returns it along with the records before it.
+func (game *Game) completeStage() stageResult {
+	stage := game.stages[game.stageIndex]
+
+	res := stageResult{
+		moves:    game.state.Moves(),
+		pushes:   game.state.Pushes(),
+		ticks:    game.ticks,
+		previous: game.progress.Stages[stage.Key()],
+		replayed: game.replay != nil,
+	}
+
+	if res.replayed {
+		return res
+	}
+
+	game.progress.record(stage.Key(), res.moves, res.pushes)
+	game.saveProgress()
+	game.saveReplay()
+
+	return res
+}
+
+// rememberStage keeps current stage to continue from it next time.
+func (game *Game) rememberStage() {
+	name := game.stages[game.stageIndex].Key()
+	if game.progress.LastStage == name {
+		return
+	}
+
+	game.progress.LastStage = name
+	game.saveProgress()
+}
+
+// restoreStage moves to the stage played last time if it still exists.
+func (game *Game) restoreStage() {
+	for i := range game.stages {
+		if game.stages[i].Key() == game.progress.LastStage {
+			game.stageIndex = i
+
+			return
+		}
+	}
+}
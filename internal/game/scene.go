@@ -0,0 +1,28 @@
+package game
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Scene is a screen of the game. Game runs exactly one of them at a time.
+type Scene interface {
+	Update(game *Game, actions []Action) error
+	Draw(game *Game, screen *ebiten.Image)
+	Layout(outsideWidth, outsideHeight int) (int, int)
+}
+
+func (game *Game) SetScene(scene Scene) {
+	game.scene = scene
+	game.shouldDraw = true
+}
+
+// screenLayout is the fixed layout every scene uses.
+func screenLayout(int, int) (int, int) {
+	return screenWidth * scaleFactor, screenHeight * scaleFactor
+}
+
+func clearScreen(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+}
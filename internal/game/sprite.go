@@ -1,272 +1,309 @@
 package game
 
 import (
-	"embed"
+	"encoding/json"
 	"image"
+	"io/fs"
+	"path"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/nasermirzaei89/shove-it/internal/level"
 	"github.com/pkg/errors"
 )
 
-const defaultFrameRate = 10
+// defaultFrameDuration is how long a frame of the atlas without a duration is shown.
+const defaultFrameDuration = time.Second / 10
 
+const (
+	LoopForward  = "loop"
+	LoopOnce     = "once"
+	LoopPingPong = "pingpong"
+)
+
+var (
+	ErrNoFrames     = errors.New("sprite has no frames")
+	ErrUnknownLoop  = errors.New("unknown loop mode")
+	ErrFrameOutside = errors.New("frame is outside of image")
+
+	ErrUnknownSprite   = errors.New("unknown sprite")
+	ErrDuplicateSprite = errors.New("sprite is defined more than once")
+)
+
+// playerSprites returns the sprites drawn by the player whatever the stages are made of, so an atlas must have them.
+func playerSprites() []SpriteName {
+	return []SpriteName{SpriteIdle, SpriteWalking, SpritePushing, SpritePushingIdle}
+}
+
+// Sprite is an animation of images, each shown for its duration. Loop is what happens after the last one.
 type Sprite struct {
-	Images []*ebiten.Image
-	Speed  float64
+	Images    []*ebiten.Image
+	Durations []time.Duration
+	Loop      string
 }
 
 type Frame struct {
 	I, J int
 }
 
-func NewSprite(img *ebiten.Image, frames []image.Rectangle, speed float64) *Sprite {
+func NewSprite(img *ebiten.Image, frames []image.Rectangle, durations []time.Duration, loop string) *Sprite {
 	images := make([]*ebiten.Image, len(frames))
 	for i := range frames {
 		images[i], _ = img.SubImage(frames[i]).(*ebiten.Image)
 	}
 
 	return &Sprite{
-		Images: images,
-		Speed:  speed,
+		Images:    images,
+		Durations: durations,
+		Loop:      loop,
+	}
+}
+
+// order returns indices of images for one run of the animation.
+func (sprite *Sprite) order() []int {
+	res := make([]int, 0, len(sprite.Images)*2)
+	for i := range sprite.Images {
+		res = append(res, i)
+	}
+
+	if sprite.Loop == LoopPingPong {
+		for i := len(sprite.Images) - 2; i > 0; i-- {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+// ImageAt returns the image shown elapsed time after the animation has started.
+func (sprite *Sprite) ImageAt(elapsed time.Duration) *ebiten.Image {
+	order := sprite.order()
+
+	var total time.Duration
+	for _, i := range order {
+		total += sprite.Durations[i]
+	}
+
+	if total <= 0 {
+		return sprite.Images[0]
+	}
+
+	if sprite.Loop == LoopOnce && elapsed >= total {
+		return sprite.Images[order[len(order)-1]]
+	}
+
+	elapsed %= total
+
+	for _, i := range order {
+		if elapsed < sprite.Durations[i] {
+			return sprite.Images[i]
+		}
+
+		elapsed -= sprite.Durations[i]
 	}
+
+	return sprite.Images[order[len(order)-1]]
+}
+
+// Atlas describes sprites as frames of images, and lists Aseprite sheets adding a sprite for each
+// of their frame tags. Images and sheets are relative to the atlas file.
+type Atlas struct {
+	Sprites  map[SpriteName]AtlasSprite `json:"sprites"`
+	Aseprite []string                   `json:"aseprite"`
 }
 
-func (game *Game) loadImages(assets embed.FS) (err error) {
+type AtlasSprite struct {
+	Image  string       `json:"image"`
+	Loop   string       `json:"loop"`
+	Frames []AtlasFrame `json:"frames"`
+}
+
+// AtlasFrame is a rectangle of the image, shown for Duration milliseconds.
+type AtlasFrame struct {
+	X        int `json:"x"`
+	Y        int `json:"y"`
+	W        int `json:"w"`
+	H        int `json:"h"`
+	Duration int `json:"duration"`
+}
+
+func (game *Game) loadImages(assets fs.FS) (err error) {
 	game.fontImage, err = loadImage(assets, "assets/font.png")
 	if err != nil {
 		return errors.Wrap(err, "error on load image")
 	}
 
-	game.playerImage, err = loadImage(assets, "assets/player.png")
+	return nil
+}
+
+// loadSprites reads the sprites of the atlas file and its Aseprite sheets.
+func (game *Game) loadSprites(assets fs.FS, filename string) error {
+	b, err := fs.ReadFile(assets, filename)
 	if err != nil {
-		return errors.Wrap(err, "error on load image")
+		return errors.Wrap(err, "error on read file from assets")
 	}
 
-	game.tileSetImage, err = loadImage(assets, "assets/tileset.png")
+	var atlas Atlas
+
+	err = json.Unmarshal(b, &atlas)
 	if err != nil {
-		return errors.Wrap(err, "error on load image")
+		return errors.Wrap(err, "error on decode atlas")
+	}
+
+	dir := path.Dir(filename)
+	defs := make(map[SpriteName]AtlasSprite, len(atlas.Sprites))
+
+	for name, def := range atlas.Sprites {
+		def.Image = path.Join(dir, def.Image)
+		defs[name] = def
+	}
+
+	for _, sheet := range atlas.Aseprite {
+		tags, err := loadAseprite(assets, path.Join(dir, sheet))
+		if err != nil {
+			return errors.Wrapf(err, "error on load aseprite sheet '%s'", sheet)
+		}
+
+		for name, def := range tags {
+			if _, ok := defs[name]; ok {
+				return errors.Wrapf(ErrDuplicateSprite, "'%s' of '%s'", name, sheet)
+			}
+
+			defs[name] = def
+		}
 	}
 
+	images := make(map[string]*ebiten.Image)
+	sprites := make(map[SpriteName]*Sprite, len(defs))
+
+	for name, def := range defs {
+		img, ok := images[def.Image]
+		if !ok {
+			img, err = loadImage(assets, def.Image)
+			if err != nil {
+				return errors.Wrapf(err, "error on load image of sprite '%s'", name)
+			}
+
+			images[def.Image] = img
+		}
+
+		sprite, err := newAtlasSprite(img, def)
+		if err != nil {
+			return errors.Wrapf(err, "error on sprite '%s'", name)
+		}
+
+		sprites[name] = sprite
+	}
+
+	for _, name := range playerSprites() {
+		if sprites[name] == nil {
+			return errors.Wrapf(ErrUnknownSprite, "atlas has no '%s'", name)
+		}
+	}
+
+	game.sprites = sprites
+
 	return nil
 }
 
-func (game *Game) loadSprites() {
-	game.sprites = map[SpriteName]*Sprite{
-		SpriteIdle: NewSprite(
-			game.playerImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, 0, tileWidth*2, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteWalking: NewSprite(
-			game.playerImage,
-			[]image.Rectangle{
-				image.Rect(0, 0, tileWidth, tileWidth),
-				image.Rect(tileWidth, 0, tileWidth*2, tileWidth),
-				image.Rect(tileWidth*2, 0, tileWidth*3, tileWidth),
-				image.Rect(tileWidth, 0, tileWidth*2, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpritePushing: NewSprite(
-			game.playerImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*3, 0, tileWidth*4, tileWidth),
-				image.Rect(tileWidth*4, 0, tileWidth*5, tileWidth),
-				image.Rect(tileWidth*5, 0, tileWidth*6, tileWidth),
-				image.Rect(tileWidth*4, 0, tileWidth*5, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpritePushingIdle: NewSprite(
-			game.playerImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*4, 0, tileWidth*5, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, 0, tileWidth, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, 0, tileWidth*2, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, 0, tileWidth*3, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground4: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*3, 0, tileWidth*4, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground5: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*4, 0, tileWidth*5, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteBackground6: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*5, 0, tileWidth*6, tileWidth),
-			},
-			defaultFrameRate,
-		),
-		SpriteWall1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, tileWidth, tileWidth, tileWidth*2),
-			},
-			defaultFrameRate,
-		),
-		SpriteWall2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, tileWidth, tileWidth*2, tileWidth*2),
-			},
-			defaultFrameRate,
-		),
-		SpriteWall3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, tileWidth, tileWidth*3, tileWidth*2),
-			},
-			defaultFrameRate,
-		),
-		SpriteWall4: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*3, tileWidth, tileWidth*4, tileWidth*2),
-			},
-			defaultFrameRate,
-		),
-		SpriteTile1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, tileWidth*2, tileWidth, tileWidth*3),
-			},
-			defaultFrameRate,
-		),
-		SpriteTile2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, tileWidth*2, tileWidth*2, tileWidth*3),
-			},
-			defaultFrameRate,
-		),
-		SpriteTile3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, tileWidth*2, tileWidth*3, tileWidth*3),
-			},
-			defaultFrameRate,
-		),
-		SpriteFlag1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, tileWidth*3, tileWidth, tileWidth*4),
-			},
-			defaultFrameRate,
-		),
-		SpriteFlag2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, tileWidth*3, tileWidth*2, tileWidth*4),
-			},
-			defaultFrameRate,
-		),
-		SpriteFlag3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, tileWidth*3, tileWidth*3, tileWidth*4),
-			},
-			defaultFrameRate,
-		),
-		SpriteBox1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, tileWidth*5, tileWidth, tileWidth*6),
-			},
-			defaultFrameRate,
-		),
-		SpriteBox2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, tileWidth*5, tileWidth*2, tileWidth*6),
-			},
-			defaultFrameRate,
-		),
-		SpriteBox3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, tileWidth*5, tileWidth*3, tileWidth*6),
-			},
-			defaultFrameRate,
-		),
-		SpriteBox4: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*3, tileWidth*5, tileWidth*4, tileWidth*6),
-			},
-			defaultFrameRate,
-		),
-		SpriteBox5: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*4, tileWidth*5, tileWidth*5, tileWidth*6),
-			},
-			defaultFrameRate,
-		),
-		SpriteBoxDone1: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(0, tileWidth*6, tileWidth, tileWidth*7),
-			},
-			defaultFrameRate,
-		),
-		SpriteBoxDone2: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth, tileWidth*6, tileWidth*2, tileWidth*7),
-			},
-			defaultFrameRate,
-		),
-		SpriteBoxDone3: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*2, tileWidth*6, tileWidth*3, tileWidth*7),
-			},
-			defaultFrameRate,
-		),
-		SpriteBoxDone4: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*3, tileWidth*6, tileWidth*4, tileWidth*7),
-			},
-			defaultFrameRate,
-		),
-		SpriteBoxDone5: NewSprite(
-			game.tileSetImage,
-			[]image.Rectangle{
-				image.Rect(tileWidth*4, tileWidth*6, tileWidth*5, tileWidth*7),
-			},
-			defaultFrameRate,
-		),
+func newAtlasSprite(img *ebiten.Image, def AtlasSprite) (*Sprite, error) {
+	if len(def.Frames) == 0 {
+		return nil, ErrNoFrames
+	}
+
+	loop := def.Loop
+
+	switch loop {
+	case "":
+		loop = LoopForward
+	case LoopForward, LoopOnce, LoopPingPong:
+	default:
+		return nil, errors.Wrapf(ErrUnknownLoop, "'%s'", loop)
+	}
+
+	rects := make([]image.Rectangle, len(def.Frames))
+	durations := make([]time.Duration, len(def.Frames))
+
+	for i, frame := range def.Frames {
+		rects[i] = image.Rect(frame.X, frame.Y, frame.X+frame.W, frame.Y+frame.H)
+		if rects[i].Empty() || !rects[i].In(img.Bounds()) {
+			return nil, errors.Wrapf(ErrFrameOutside, "frame %d", i)
+		}
+
+		durations[i] = time.Duration(frame.Duration) * time.Millisecond
+		if frame.Duration <= 0 {
+			durations[i] = defaultFrameDuration
+		}
+	}
+
+	return NewSprite(img, rects, durations, loop), nil
+}
+
+// imageSprite names the sprite of a tile set picture, or nothing without one.
+func imageSprite(img *level.TileImage) SpriteName {
+	if img == nil {
+		return ""
+	}
+
+	return SpriteName(img.Source + "#" + img.Rect.String())
+}
+
+// tileSprite returns the sprite a tile is drawn with, its sprite or else its picture in the tile set.
+func tileSprite(tile level.Tile) SpriteName {
+	if tile.Sprite != "" {
+		return SpriteName(tile.Sprite)
+	}
+
+	return imageSprite(tile.Image)
+}
+
+// boxSprites returns the sprites of a box tile off and on a flag. The sprite on a flag is empty
+// for boxes drawn with sprites of the atlas, which have done sprites of their own.
+func boxSprites(tile level.Tile) (SpriteName, SpriteName) {
+	if tile.Sprite != "" {
+		return SpriteName(tile.Sprite), ""
+	}
+
+	sprite, goalSprite := imageSprite(tile.Image), imageSprite(tile.OnGoal)
+
+	if tile.OffGoal != nil {
+		sprite = imageSprite(tile.OffGoal)
+	}
+
+	if tile.Goal && tile.OnGoal == nil {
+		goalSprite = imageSprite(tile.Image)
+	}
+
+	return sprite, goalSprite
+}
+
+// addTileSprites makes sprites of the tile set pictures of a stage, once for each picture.
+// Pictures cut from one image share an ebiten image, kept in images.
+func (game *Game) addTileSprites(stg level.Stage, images map[image.Image]*ebiten.Image) {
+	tiles := make([]level.Tile, 0, len(stg.Tiles)+len(stg.Spawns))
+	for _, tile := range stg.Tiles {
+		tiles = append(tiles, tile)
+	}
+
+	for _, spawn := range stg.Spawns {
+		tiles = append(tiles, spawn.Tile)
+	}
+
+	for _, tile := range tiles {
+		for _, img := range []*level.TileImage{tile.Image, tile.OnGoal, tile.OffGoal} {
+			name := imageSprite(img)
+			if img == nil || game.sprites[name] != nil {
+				continue
+			}
+
+			src, ok := images[img.Image]
+			if !ok {
+				src = ebiten.NewImageFromImage(img.Image)
+				images[img.Image] = src
+			}
+
+			rect := img.Rect.Sub(img.Image.Bounds().Min)
+			game.sprites[name] = NewSprite(src, []image.Rectangle{rect}, []time.Duration{defaultFrameDuration}, LoopForward)
+		}
 	}
 }
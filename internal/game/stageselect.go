@@ -0,0 +1,185 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/nasermirzaei89/shove-it/internal/level"
+)
+
+const (
+	stageSelectTitleY = 1
+	stageSelectListX  = 2
+	stageSelectListY  = 4
+	stageSelectRows   = 20
+	stageSelectBestX  = 24
+	stageSelectInfoY  = 25
+	// stageSelectNameLen keeps names clear of the best scores.
+	stageSelectNameLen = stageSelectBestX - stageSelectListX - 3
+)
+
+// noStage marks a section title row of the stage list.
+const noStage = -1
+
+// stageSelectRow is a line of the stage list, a stage or the title of a section.
+type stageSelectRow struct {
+	stage int
+	title string
+}
+
+// stageSelectScene lists all stages under the titles of their packs and sections with their best scores.
+type stageSelectScene struct {
+	rows     []stageSelectRow
+	selected int
+	top      int
+}
+
+func newStageSelectScene(game *Game) *stageSelectScene {
+	scene := &stageSelectScene{
+		rows:     make([]stageSelectRow, 0, len(game.stages)),
+		selected: game.stageIndex,
+		top:      0,
+	}
+
+	offset := 0
+
+	for _, pack := range game.packs {
+		if len(game.packs) > 1 {
+			scene.rows = append(scene.rows, stageSelectRow{stage: noStage, title: pack.Label()})
+		}
+
+		for _, section := range pack.Sections {
+			if section.Title != "" {
+				scene.rows = append(scene.rows, stageSelectRow{stage: noStage, title: section.Title})
+			}
+
+			for i := section.Start; i < section.End; i++ {
+				scene.rows = append(scene.rows, stageSelectRow{stage: offset + i, title: ""})
+			}
+		}
+
+		offset += len(pack.Stages)
+	}
+
+	scene.scroll()
+
+	return scene
+}
+
+// rowOf returns the row of a stage.
+func (scene *stageSelectScene) rowOf(stage int) int {
+	for i := range scene.rows {
+		if scene.rows[i].stage == stage {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// scroll keeps the selected stage visible, with the titles right above it.
+func (scene *stageSelectScene) scroll() {
+	row := scene.rowOf(scene.selected)
+
+	top := row
+	for top > 0 && scene.rows[top-1].stage == noStage {
+		top--
+	}
+
+	if top < scene.top {
+		scene.top = top
+	}
+
+	if row >= scene.top+stageSelectRows {
+		scene.top = row - stageSelectRows + 1
+	}
+}
+
+func (scene *stageSelectScene) Update(game *Game, actions []Action) error {
+	count := len(game.stages)
+
+	for _, action := range actions {
+		switch action {
+		case ActionMenuUp:
+			scene.selected = (scene.selected + count - 1) % count
+		case ActionMenuDown:
+			scene.selected = (scene.selected + 1) % count
+		case ActionMenuLeft:
+			scene.selected -= stageSelectRows
+			if scene.selected < 0 {
+				scene.selected = 0
+			}
+		case ActionMenuRight:
+			scene.selected += stageSelectRows
+			if scene.selected >= count {
+				scene.selected = count - 1
+			}
+		case ActionConfirm:
+			game.stageIndex = scene.selected
+			game.startStage()
+			game.SetScene(newPlayScene())
+
+			return nil
+		case ActionBack:
+			game.SetScene(newTitleScene())
+
+			return nil
+		default:
+			continue
+		}
+
+		scene.scroll()
+
+		game.shouldDraw = true
+	}
+
+	return nil
+}
+
+func (scene *stageSelectScene) Draw(game *Game, screen *ebiten.Image) {
+	clearScreen(screen)
+
+	game.DrawTextCentered(screen, stageSelectTitleY, "SELECT STAGE")
+
+	for row := 0; row < stageSelectRows && scene.top+row < len(scene.rows); row++ {
+		i := scene.rows[scene.top+row].stage
+		if i == noStage {
+			game.DrawText(screen, stageSelectListX, stageSelectListY+row, fitText(scene.rows[scene.top+row].title, stageSelectBestX-stageSelectListX))
+
+			continue
+		}
+
+		stg := game.stages[i]
+
+		cursor := "  "
+		if i == scene.selected {
+			cursor = menuCursor
+		}
+
+		game.DrawText(screen, stageSelectListX, stageSelectListY+row, cursor+fitText(stg.DisplayName(), stageSelectNameLen))
+
+		if rec, ok := game.progress.Stages[stg.Key()]; ok && rec.Completed {
+			game.DrawText(screen, stageSelectBestX, stageSelectListY+row, fmt.Sprintf("* %d/%d", rec.BestMoves, rec.BestPushes))
+		}
+	}
+
+	scene.drawInfo(game, screen, game.stages[scene.selected])
+}
+
+// drawInfo shows author, difficulty and par of the selected stage under the list.
+func (scene *stageSelectScene) drawInfo(game *Game, screen *ebiten.Image, stg level.Stage) {
+	if stg.Author != "" {
+		game.DrawText(screen, stageSelectListX, stageSelectInfoY, "BY "+stg.Author)
+	}
+
+	info := fmt.Sprintf("PAR %s/%s", formatCount(stg.ParMoves), formatCount(stg.ParPushes))
+	if stg.Difficulty != "" {
+		info = stg.Difficulty + "  " + info
+	}
+
+	game.DrawText(screen, stageSelectListX, stageSelectInfoY+1, info)
+}
+
+func (*stageSelectScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,108 @@
+//go:build !js
+
+package game
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	storageDir      = "shove-it"
+	storageDirPerm  = 0o755
+	storageFilePerm = 0o644
+)
+
+// storagePath maps a slash separated name to a file under the user config dir.
+func storagePath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "error on get user config dir")
+	}
+
+	return filepath.Join(dir, storageDir, filepath.FromSlash(name)), nil
+}
+
+// readData returns nil without error when nothing is stored as name.
+func readData(name string) ([]byte, error) {
+	path, err := storagePath(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on get storage path")
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read file")
+	}
+
+	return b, nil
+}
+
+func writeData(name string, b []byte) error {
+	path, err := storagePath(name)
+	if err != nil {
+		return errors.Wrap(err, "error on get storage path")
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), storageDirPerm)
+	if err != nil {
+		return errors.Wrap(err, "error on make dir")
+	}
+
+	// written aside and renamed over the old file, so a crash never leaves it half written
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "error on create temp file")
+	}
+
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = file.Write(b)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "error on write temp file")
+	}
+
+	err = os.Chmod(file.Name(), storageFilePerm)
+	if err != nil {
+		return errors.Wrap(err, "error on chmod temp file")
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return errors.Wrap(err, "error on rename temp file")
+	}
+
+	return nil
+}
+
+// exportData writes b as name next to the save data, where the player can open it.
+func exportData(name string, b []byte) (string, error) {
+	err := writeData(name, b)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := storagePath(name)
+	if err != nil {
+		return "", errors.Wrap(err, "error on get storage path")
+	}
+
+	log.Printf("exported to %s", path)
+
+	return "SAVE FOLDER", nil
+}
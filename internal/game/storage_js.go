@@ -0,0 +1,68 @@
+//go:build js
+
+package game
+
+import (
+	"syscall/js"
+
+	"github.com/pkg/errors"
+)
+
+const storagePrefix = "shove-it/"
+
+var (
+	ErrNoLocalStorage = errors.New("local storage is not available")
+	ErrNoClipboard    = errors.New("clipboard is not available")
+)
+
+func localStorage() (js.Value, error) {
+	storage := js.Global().Get("localStorage")
+	if storage.IsUndefined() || storage.IsNull() {
+		return js.Undefined(), ErrNoLocalStorage
+	}
+
+	return storage, nil
+}
+
+// readData returns nil without error when nothing is stored as name.
+func readData(name string) ([]byte, error) {
+	storage, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	item := storage.Call("getItem", storagePrefix+name)
+	if item.IsNull() {
+		return nil, nil
+	}
+
+	return []byte(item.String()), nil
+}
+
+func writeData(name string, b []byte) error {
+	storage, err := localStorage()
+	if err != nil {
+		return err
+	}
+
+	storage.Call("setItem", storagePrefix+name, string(b))
+
+	return nil
+}
+
+// exportData copies b to the clipboard, as files stored by the browser can't be opened by the player.
+func exportData(_ string, b []byte) (string, error) {
+	navigator := js.Global().Get("navigator")
+	if navigator.IsUndefined() || navigator.IsNull() {
+		return "", ErrNoClipboard
+	}
+
+	clipboard := navigator.Get("clipboard")
+	if clipboard.IsUndefined() || clipboard.IsNull() {
+		return "", ErrNoClipboard
+	}
+
+	clipboard.Call("writeText", string(b))
+
+	return "CLIPBOARD", nil
+}
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	titleY     = 8
+	titleMenuY = 14
+	titleSaveY = 22
+)
+
+const (
+	titleStart = iota
+	titleStageSelect
+	titleCredits
+)
+
+type titleScene struct {
+	menu *menu
+}
+
+func newTitleScene() *titleScene {
+	return &titleScene{
+		menu: newMenu("START", "STAGE SELECT", "CREDITS"),
+	}
+}
+
+func (scene *titleScene) Update(game *Game, actions []Action) error {
+	switch scene.menu.Update(game, actions) {
+	case titleStart:
+		game.startStage()
+		game.SetScene(newPlayScene())
+	case titleStageSelect:
+		game.SetScene(newStageSelectScene(game))
+	case titleCredits:
+		game.SetScene(newCreditsScene(game))
+	}
+
+	return nil
+}
+
+func (scene *titleScene) Draw(game *Game, screen *ebiten.Image) {
+	clearScreen(screen)
+
+	game.DrawTextCentered(screen, titleY, "SHOVE IT")
+	scene.menu.Draw(game, screen, titleMenuY)
+
+	if game.keepSave {
+		game.DrawTextCentered(screen, titleSaveY, "SAVE NOT LOADED, PROGRESS NOT SAVED")
+	}
+}
+
+func (*titleScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenLayout(outsideWidth, outsideHeight)
+}
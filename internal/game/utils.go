@@ -2,17 +2,15 @@ package game
 
 import (
 	"bytes"
-	"embed"
-	"encoding/csv"
-	"encoding/xml"
+	"image"
+	"io/fs"
+	"log"
 	"math"
-	"path/filepath"
-	"sort"
-	"strconv"
-	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
 	"github.com/pkg/errors"
 )
 
@@ -26,6 +24,11 @@ const (
 	defaultSizeY  = 10
 )
 
+var (
+	ErrDuplicatePack = errors.New("pack is added more than once, rename its directory or file")
+	ErrNoStages      = errors.New("no playable stages")
+)
+
 const (
 	directionRight = 0
 	directionDown  = .5 * math.Pi
@@ -33,6 +36,34 @@ const (
 	directionUp    = 1.5 * math.Pi
 )
 
+func rotation(dir sokoban.Direction) float64 {
+	switch dir {
+	case sokoban.Left:
+		return directionLeft
+	case sokoban.Right:
+		return directionRight
+	case sokoban.Up:
+		return directionUp
+	case sokoban.Down:
+		return directionDown
+	default:
+		return directionUp
+	}
+}
+
+// approach moves current towards desired by at most speed without passing it.
+func approach(current, desired, speed float64) float64 {
+	if math.Abs(desired-current) <= speed {
+		return desired
+	}
+
+	if desired < current {
+		return current - speed
+	}
+
+	return current + speed
+}
+
 type SpriteName string
 
 const (
@@ -75,10 +106,26 @@ const (
 )
 
 func (game *Game) scale() float64 {
-	scaleX := float64(scaleFactor*defaultSizeX) / float64(game.stages[game.stageIndex].TMX.Width)
-	scaleY := float64(scaleFactor*defaultSizeY) / float64(game.stages[game.stageIndex].TMX.Height)
+	return game.zoom
+}
+
+// fitScale returns the scale of a stage, shown as large as the shipped ones of its size but made
+// smaller if its walls don't fit the screen above the HUD. Background past the walls may be cut off.
+func fitScale(stg level.Stage) float64 {
+	res := math.Min(
+		float64(scaleFactor*defaultSizeX)/float64(stg.TMX.Width),
+		float64(scaleFactor*defaultSizeY)/float64(stg.TMX.Height),
+	)
+
+	width, height := stg.Extent()
+	if width == 0 || height == 0 {
+		return res
+	}
+
+	scaleX := float64(screenWidth*scaleFactor) / float64(width*tileWidth)
+	scaleY := float64(hudTopY*characterWidth*scaleFactor) / float64(height*tileWidth)
 
-	return math.Min(scaleX, scaleY)
+	return math.Min(res, math.Min(scaleX, scaleY))
 }
 
 func (game *Game) createObjectAt(spriteName SpriteName, i, j int) {
@@ -100,23 +147,25 @@ func (game *Game) createPlayerAt(i, j int) {
 		currentSprite: SpriteIdle,
 		idle:          true,
 		pushing:       false,
-		history:       make([]int, 0),
-		boxHistory:    make([]*Box, 0),
 	}
 }
 
-func (game *Game) createBoxAt(spriteName SpriteName, i, j int) {
+func (game *Game) createBoxAt(tile level.Tile, i, j int) {
+	sprite, goalSprite := boxSprites(tile)
+
 	game.boxes = append(game.boxes, &Box{
 		PositionX:  float64(i * tileWidth),
 		PositionY:  float64(j * tileWidth),
 		I:          i,
 		J:          j,
-		SpriteName: spriteName,
+		SpriteName: sprite,
+		GoalSprite: goalSprite,
+		deadlocked: false,
 	})
 }
 
-func loadImage(assets embed.FS, filename string) (*ebiten.Image, error) {
-	b, err := assets.ReadFile(filename)
+func loadImage(assets fs.FS, filename string) (*ebiten.Image, error) {
+	b, err := fs.ReadFile(assets, filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "error on read file from assets")
 	}
@@ -129,70 +178,54 @@ func loadImage(assets embed.FS, filename string) (*ebiten.Image, error) {
 	return img, nil
 }
 
-func (game *Game) loadStages(assets embed.FS, dir string) error {
-	entries, err := assets.ReadDir(dir)
-	if err != nil {
-		return errors.Wrap(err, "error on read directory")
-	}
-
-	res := make([]Stage, 0)
+// addPacks validates stages of packs and lines them up to be played one pack after another.
+// A broken stage is logged and left out, so a broken mod or extra pack doesn't stop the game.
+// Packs keep progress apart by ID, so no two may share one.
+func (game *Game) addPacks(packs []level.Pack) error {
+	images := make(map[image.Image]*ebiten.Image)
 
-	for _, entry := range entries {
-		ext := filepath.Ext(entry.Name())
-		if ext != ".tmx" {
-			continue
+	for _, pack := range packs {
+		for _, added := range game.packs {
+			if added.ID == pack.ID {
+				return errors.Wrapf(ErrDuplicatePack, "'%s'", pack.ID)
+			}
 		}
 
-		file, err := assets.Open(dir + "/" + entry.Name())
-		if err != nil {
-			return errors.Wrap(err, "error on open file")
+		for i := range pack.Stages {
+			pack.Stages[i].Pack = pack.ID
 		}
 
-		defer func() { _ = file.Close() }()
+		label := pack.Label()
 
-		var tmx TMX
-
-		err = xml.NewDecoder(file).Decode(&tmx)
-		if err != nil {
-			return errors.Wrap(err, "error on decode tmx file")
-		}
+		pack = pack.Filter(func(stg level.Stage) bool {
+			err := stg.Validate()
+			if err == nil {
+				game.addTileSprites(stg, images)
+				err = game.checkSprites(stg)
+			}
 
-		csvReader := csv.NewReader(bytes.NewBufferString(strings.ReplaceAll(tmx.Data, ",\n", "\n")))
+			if err != nil {
+				log.Printf("skipping stage of pack '%s': %v", label, err)
 
-		records, err := csvReader.ReadAll()
-		if err != nil {
-			return errors.Wrap(err, "error on read all from csv reader")
-		}
+				return false
+			}
 
-		data := make([][]int, len(records))
-		for j := range records {
-			data[j] = make([]int, len(records[j]))
+			return true
+		})
 
-			for i := range records[j] {
-				v, err := strconv.Atoi(records[j][i])
-				if err != nil {
-					return errors.Wrap(err, "error on convert string to int")
-				}
+		if len(pack.Stages) == 0 {
+			log.Printf("skipping pack '%s', it has no playable stage", label)
 
-				data[j][i] = v
-			}
+			continue
 		}
 
-		res = append(res, Stage{
-			Name: strings.ReplaceAll(entry.Name(), ext, ""),
-			Data: data,
-			TMX:  tmx,
-		})
+		game.packs = append(game.packs, pack)
+		game.stages = append(game.stages, pack.Stages...)
 	}
 
-	sort.SliceStable(res, func(i, j int) bool {
-		a, _ := strconv.Atoi(res[i].Name)
-		b, _ := strconv.Atoi(res[j].Name)
-
-		return a < b
-	})
-
-	game.stages = res
+	if len(game.stages) == 0 {
+		return ErrNoStages
+	}
 
 	return nil
 }
@@ -217,117 +250,116 @@ func (game *Game) prevStage() {
 	game.startStage()
 }
 
-func (game *Game) getThemes() (tileTheme, flagTheme SpriteName) {
-	data := game.stages[game.stageIndex].Data
+// checkSprites makes sure every tile the stage uses is drawn with a loaded sprite.
+func (game *Game) checkSprites(stg level.Stage) error {
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			for _, tile := range stg.Cell(i, j) {
+				sprite := tileSprite(tile)
 
-	for j := range data {
-		for i := range data[j] {
-			switch data[j][i] {
-			case ItemTile1:
-				tileTheme = SpriteTile1
-				flagTheme = SpriteFlag1
+				if tile.Box && sprite == "" {
+					return &level.StageError{Stage: stg.Name, I: i, J: j, Err: errors.Wrap(ErrUnknownSprite, "box without sprite")}
+				}
 
-				return
-			case ItemTile2:
-				tileTheme = SpriteTile2
-				flagTheme = SpriteFlag2
+				if sprite != "" && game.sprites[sprite] == nil {
+					return &level.StageError{Stage: stg.Name, I: i, J: j, Err: errors.Wrapf(ErrUnknownSprite, "%s", sprite)}
+				}
+			}
+		}
+	}
 
-				return
-			case ItemTile3:
-				tileTheme = SpriteTile3
-				flagTheme = SpriteFlag3
+	return nil
+}
 
-				return
-			}
+// createCellAt creates the objects of every tile at (i, j) from bottom to top. A player or box
+// with nothing drawn under it stands on the floor or flag of its theme.
+func (game *Game) createCellAt(stg level.Stage, i, j int) {
+	cell := stg.Tile(i, j)
+	ground := false
+	box := level.Tile{Wall: false, Floor: false, Goal: false, Box: false, Player: false, Theme: 0, Sprite: "", Image: nil, OnGoal: nil, OffGoal: nil}
+
+	for _, tile := range stg.Cell(i, j) {
+		switch {
+		case tile.Box:
+			box = tile
+		case tile.Player:
+		case tileSprite(tile) != "":
+			game.createObjectAt(tileSprite(tile), i, j)
+			ground = true
+		}
+	}
+
+	if !ground && (cell.Box || cell.Player) {
+		theme := stg.Theme
+		if cell.Theme != 0 {
+			theme = cell.Theme
+		}
+
+		under, flag := stg.ThemeTiles(theme)
+		if cell.Goal {
+			under = flag
+		}
+
+		if sprite := tileSprite(under); sprite != "" {
+			game.createObjectAt(sprite, i, j)
 		}
 	}
 
-	return
+	if cell.Player {
+		game.createPlayerAt(i, j)
+	}
+
+	if cell.Box {
+		game.createBoxAt(box, i, j)
+	}
 }
 
 func (game *Game) startStage() {
-	data := game.stages[game.stageIndex].Data
+	stg := game.stages[game.stageIndex]
+
+	game.stopHint()
+	game.setMessage(stg.Hint)
+	game.rememberStage()
+
+	game.replay = nil
+	game.ticks = 0
+	game.moveTicks = make([]int, 0)
+	game.restarts = make([]restartPoint, 0)
+
+	game.state = stg.NewState()
+	game.deadlockAt = -1
+	game.zoom = fitScale(stg)
 
 	game.objects = make([]*object, 0)
 	game.boxes = make([]*Box, 0)
 
-	tileTheme, flagTheme := game.getThemes()
-
-	for j := range data {
-		for i := range data[j] {
-			switch data[j][i] {
-			case ItemBackground1:
-				game.createObjectAt(SpriteBackground1, i, j)
-			case ItemBackground2:
-				game.createObjectAt(SpriteBackground2, i, j)
-			case ItemBackground3:
-				game.createObjectAt(SpriteBackground3, i, j)
-			case ItemBackground4:
-				game.createObjectAt(SpriteBackground4, i, j)
-			case ItemBackground5:
-				game.createObjectAt(SpriteBackground5, i, j)
-			case ItemWall1:
-				game.createObjectAt(SpriteWall1, i, j)
-			case ItemWall2:
-				game.createObjectAt(SpriteWall2, i, j)
-			case ItemWall3:
-				game.createObjectAt(SpriteWall3, i, j)
-			case ItemWall4:
-				game.createObjectAt(SpriteWall4, i, j)
-			case ItemTile1:
-				game.createObjectAt(SpriteTile1, i, j)
-			case ItemTile2:
-				game.createObjectAt(SpriteTile2, i, j)
-			case ItemTile3:
-				game.createObjectAt(SpriteTile3, i, j)
-			case ItemTileFlagged1:
-				game.createObjectAt(SpriteFlag1, i, j)
-			case ItemTileFlagged2:
-				game.createObjectAt(SpriteFlag2, i, j)
-			case ItemTileFlagged3:
-				game.createObjectAt(SpriteFlag3, i, j)
-			case ItemPlayer1:
-				game.createObjectAt(SpriteTile1, i, j)
-				game.createPlayerAt(i, j)
-			case ItemPlayer2:
-				game.createObjectAt(SpriteTile2, i, j)
-				game.createPlayerAt(i, j)
-			case ItemPlayer3:
-				game.createObjectAt(SpriteTile3, i, j)
-				game.createPlayerAt(i, j)
-			case ItemBox1:
-				game.createObjectAt(tileTheme, i, j)
-				game.createBoxAt(SpriteBox1, i, j)
-			case ItemBox2:
-				game.createObjectAt(tileTheme, i, j)
-				game.createBoxAt(SpriteBox2, i, j)
-			case ItemBox3:
-				game.createObjectAt(tileTheme, i, j)
-				game.createBoxAt(SpriteBox3, i, j)
-			case ItemBox4:
-				game.createObjectAt(tileTheme, i, j)
-				game.createBoxAt(SpriteBox4, i, j)
-			case ItemBox5:
-				game.createObjectAt(tileTheme, i, j)
-				game.createBoxAt(SpriteBox5, i, j)
-			case ItemBoxDone1:
-				game.createObjectAt(flagTheme, i, j)
-				game.createBoxAt(SpriteBox1, i, j)
-			case ItemBoxDone2:
-				game.createObjectAt(flagTheme, i, j)
-				game.createBoxAt(SpriteBox2, i, j)
-			case ItemBoxDone3:
-				game.createObjectAt(flagTheme, i, j)
-				game.createBoxAt(SpriteBox3, i, j)
-			case ItemBoxDone4:
-				game.createObjectAt(flagTheme, i, j)
-				game.createBoxAt(SpriteBox4, i, j)
-			case ItemBoxDone5:
-				game.createObjectAt(flagTheme, i, j)
-				game.createBoxAt(SpriteBox5, i, j)
-			}
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			game.createCellAt(stg, i, j)
 		}
 	}
 
 	game.shouldDraw = true
 }
+
+// syncPositions moves player and boxes targets to their cells in state.
+func (game *Game) syncPositions() {
+	if game.player != nil {
+		pos := game.state.Player()
+		game.player.I, game.player.J = pos.I, pos.J
+	}
+
+	for i := range game.boxes {
+		game.syncBox(i)
+	}
+}
+
+// syncBox moves target of a single box, or nothing for sokoban.NoBox.
+func (game *Game) syncBox(box int) {
+	if box == sokoban.NoBox {
+		return
+	}
+
+	pos := game.state.Box(box)
+	game.boxes[box].I, game.boxes[box].J = pos.I, pos.J
+}
@@ -0,0 +1,54 @@
+package level
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LoadPackPath reads a pack from a directory, a pack manifest or a single stage file on disk.
+// The pack is identified by its directory name, or file name for a single stage file.
+func LoadPackPath(name string) (Pack, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on get absolute path")
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on stat levels")
+	}
+
+	// rooted at the volume, so maps can refer to tile sets outside of the levels directory
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on get relative path")
+	}
+
+	var pack Pack
+
+	if info.IsDir() {
+		pack, err = LoadPack(os.DirFS(root), filepath.ToSlash(rel))
+	} else {
+		pack, err = LoadPackFile(os.DirFS(root), filepath.ToSlash(rel))
+	}
+
+	if err != nil {
+		return Pack{}, errors.Wrapf(err, "error on load levels '%s'", name)
+	}
+
+	switch {
+	case info.IsDir():
+		pack.ID = filepath.Base(abs)
+	case IsManifest(filepath.Base(abs)):
+		pack.ID = filepath.Base(filepath.Dir(abs))
+	default:
+		pack.ID = strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
+	}
+
+	return pack, nil
+}
@@ -0,0 +1,265 @@
+package level
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	EncodingXML    = ""
+	EncodingCSV    = "csv"
+	EncodingBase64 = "base64"
+
+	CompressionNone = ""
+	CompressionZlib = "zlib"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// gidSize is the size of a gid in base64 encoded layers, as little endian uint32.
+const gidSize = 4
+
+var (
+	ErrUnknownEncoding    = errors.New("unknown layer encoding")
+	ErrUnknownCompression = errors.New("unknown layer compression")
+)
+
+// TMXData is the tile data of a layer with the encoding and compression it was saved with,
+// so Stage.EncodeLayer can write it back the same way. Without an encoding, which older Tiled
+// versions call XML, each gid is a tile element.
+type TMXData struct {
+	Encoding    string    `xml:"encoding,attr"`
+	Compression string    `xml:"compression,attr"`
+	Value       string    `xml:",chardata"`
+	Tiles       []TMXTile `xml:"tile"`
+}
+
+type TMXTile struct {
+	GID int `xml:"gid,attr"`
+}
+
+// decodeTMXData decodes a layer into rows of gids. Binary layers are split by width,
+// and must have width * height gids.
+func decodeTMXData(d TMXData, width, height int) ([][]int, error) {
+	switch d.Encoding {
+	case EncodingXML:
+		return decodeXML(d.Tiles, width, height)
+	case EncodingCSV:
+		return decodeCSV(d.Value)
+	case EncodingBase64:
+		return decodeBase64(d, width, height)
+	default:
+		return nil, errors.Wrapf(ErrUnknownEncoding, "'%s'", d.Encoding)
+	}
+}
+
+func decodeXML(tiles []TMXTile, width, height int) ([][]int, error) {
+	if len(tiles) != width*height {
+		return nil, errors.Wrapf(ErrSizeMismatch, "%d gids for %dx%d", len(tiles), width, height)
+	}
+
+	data := make([][]int, height)
+	for j := range data {
+		data[j] = make([]int, width)
+
+		for i := range data[j] {
+			data[j][i] = tiles[j*width+i].GID
+		}
+	}
+
+	return data, nil
+}
+
+func decodeCSV(value string) ([][]int, error) {
+	csvReader := csv.NewReader(bytes.NewBufferString(strings.ReplaceAll(value, ",\n", "\n")))
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read all from csv reader")
+	}
+
+	data := make([][]int, len(records))
+	for j := range records {
+		data[j] = make([]int, len(records[j]))
+
+		for i := range records[j] {
+			v, err := strconv.Atoi(records[j][i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on convert string to int at (%d, %d)", i, j)
+			}
+
+			data[j][i] = v
+		}
+	}
+
+	return data, nil
+}
+
+func decodeBase64(d TMXData, width, height int) ([][]int, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(d.Value))
+	if err != nil {
+		return nil, errors.Wrap(err, "error on decode base64")
+	}
+
+	b, err = decompress(b, d.Compression)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on decompress")
+	}
+
+	if len(b) != width*height*gidSize {
+		return nil, errors.Wrapf(ErrSizeMismatch, "%d gids for %dx%d", len(b)/gidSize, width, height)
+	}
+
+	data := make([][]int, height)
+	for j := range data {
+		data[j] = make([]int, width)
+
+		for i := range data[j] {
+			data[j][i] = int(binary.LittleEndian.Uint32(b[(j*width+i)*gidSize:]))
+		}
+	}
+
+	return data, nil
+}
+
+func decompress(b []byte, compression string) ([]byte, error) {
+	var (
+		r   io.ReadCloser
+		err error
+	)
+
+	switch compression {
+	case CompressionNone:
+		return b, nil
+	case CompressionZlib:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	case CompressionGzip:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case CompressionZstd:
+		var dec *zstd.Decoder
+
+		dec, err = zstd.NewReader(bytes.NewReader(b))
+		if err == nil {
+			r = dec.IOReadCloser()
+		}
+	default:
+		return nil, errors.Wrapf(ErrUnknownCompression, "'%s'", compression)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error on new reader")
+	}
+
+	defer func() { _ = r.Close() }()
+
+	res, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read all")
+	}
+
+	return res, nil
+}
+
+// encodeTMXData encodes rows of gids with the given encoding and compression.
+func encodeTMXData(data [][]int, encoding, compression string) (TMXData, error) {
+	res := TMXData{Encoding: encoding, Compression: compression, Value: "", Tiles: nil}
+
+	switch encoding {
+	case EncodingXML:
+		for j := range data {
+			for i := range data[j] {
+				res.Tiles = append(res.Tiles, TMXTile{GID: data[j][i]})
+			}
+		}
+	case EncodingCSV:
+		rows := make([]string, len(data))
+		for j := range data {
+			cells := make([]string, len(data[j]))
+			for i := range data[j] {
+				cells[i] = strconv.Itoa(data[j][i])
+			}
+
+			rows[j] = strings.Join(cells, ",")
+		}
+
+		res.Value = "\n" + strings.Join(rows, ",\n") + "\n"
+	case EncodingBase64:
+		b := make([]byte, 0)
+		for j := range data {
+			for i := range data[j] {
+				gid := make([]byte, gidSize)
+				binary.LittleEndian.PutUint32(gid, uint32(data[j][i]))
+				b = append(b, gid...)
+			}
+		}
+
+		b, err := compress(b, compression)
+		if err != nil {
+			return TMXData{}, errors.Wrap(err, "error on compress")
+		}
+
+		res.Value = base64.StdEncoding.EncodeToString(b)
+	default:
+		return TMXData{}, errors.Wrapf(ErrUnknownEncoding, "'%s'", encoding)
+	}
+
+	return res, nil
+}
+
+func compress(b []byte, compression string) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+
+	switch compression {
+	case CompressionNone:
+		return b, nil
+	case CompressionZlib:
+		w = zlib.NewWriter(&buf)
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionZstd:
+		w, err = zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, errors.Wrap(err, "error on new writer")
+		}
+	default:
+		return nil, errors.Wrapf(ErrUnknownCompression, "'%s'", compression)
+	}
+
+	_, err = w.Write(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on write")
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "error on close writer")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncodeLayer encodes a layer of the stage the way it was saved, CSV for stages not read from TMX.
+func (stg Stage) EncodeLayer(layer int) (TMXData, error) {
+	if layer >= len(stg.TMX.Layers) {
+		return encodeTMXData(stg.Layers[layer], EncodingCSV, CompressionNone)
+	}
+
+	d := stg.TMX.Layers[layer].Data
+
+	return encodeTMXData(stg.Layers[layer], d.Encoding, d.Compression)
+}
@@ -0,0 +1,140 @@
+package level
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestDecodeTMXData(t *testing.T) {
+	tests := []struct {
+		name string
+		data TMXData
+		want [][]int
+		err  error
+	}{
+		{
+			name: "xml",
+			data: TMXData{Encoding: EncodingXML, Compression: CompressionNone, Value: "", Tiles: []TMXTile{{GID: 1}, {GID: 2}, {GID: 3}, {GID: 4}, {GID: 5}, {GID: 6}}},
+			want: [][]int{{1, 2, 3}, {4, 5, 6}},
+			err:  nil,
+		},
+		{
+			name: "csv",
+			data: TMXData{Encoding: EncodingCSV, Compression: CompressionNone, Value: "\n1,2,3,\n4,5,6\n", Tiles: nil},
+			want: [][]int{{1, 2, 3}, {4, 5, 6}},
+			err:  nil,
+		},
+		{
+			name: "base64",
+			data: TMXData{Encoding: EncodingBase64, Compression: CompressionNone, Value: " AQAAAAIAAAADAAAABAAAAAUAAAAGAAAA\n", Tiles: nil},
+			want: [][]int{{1, 2, 3}, {4, 5, 6}},
+			err:  nil,
+		},
+		{
+			name: "base64 zlib",
+			data: TMXData{Encoding: EncodingBase64, Compression: CompressionZlib, Value: "eJxjZGBgYAJiZiBmAWJWIGYDYgAA+AAW", Tiles: nil},
+			want: [][]int{{1, 2, 3}, {4, 5, 6}},
+			err:  nil,
+		},
+		{
+			name: "xml of another size",
+			data: TMXData{Encoding: EncodingXML, Compression: CompressionNone, Value: "", Tiles: []TMXTile{{GID: 1}}},
+			want: nil,
+			err:  ErrSizeMismatch,
+		},
+		{
+			name: "base64 of another size",
+			data: TMXData{Encoding: EncodingBase64, Compression: CompressionNone, Value: "AQAAAA==", Tiles: nil},
+			want: nil,
+			err:  ErrSizeMismatch,
+		},
+		{
+			name: "unknown encoding",
+			data: TMXData{Encoding: "hex", Compression: CompressionNone, Value: "", Tiles: nil},
+			want: nil,
+			err:  ErrUnknownEncoding,
+		},
+		{
+			name: "unknown compression",
+			data: TMXData{Encoding: EncodingBase64, Compression: "lzma", Value: "AQAAAA==", Tiles: nil},
+			want: nil,
+			err:  ErrUnknownCompression,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTMXData(tt.data, 3, 2)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("decodeTMXData() error = %v, want %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeTMXData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeTMXDataRoundTrip(t *testing.T) {
+	data := [][]int{{1, 2, 3}, {4, 0, 6}}
+
+	tests := []struct {
+		name        string
+		encoding    string
+		compression string
+	}{
+		{name: "xml", encoding: EncodingXML, compression: CompressionNone},
+		{name: "csv", encoding: EncodingCSV, compression: CompressionNone},
+		{name: "base64", encoding: EncodingBase64, compression: CompressionNone},
+		{name: "zlib", encoding: EncodingBase64, compression: CompressionZlib},
+		{name: "gzip", encoding: EncodingBase64, compression: CompressionGzip},
+		{name: "zstd", encoding: EncodingBase64, compression: CompressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeTMXData(data, tt.encoding, tt.compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if encoded.Encoding != tt.encoding || encoded.Compression != tt.compression {
+				t.Errorf("encoded as %q %q, want %q %q", encoded.Encoding, encoded.Compression, tt.encoding, tt.compression)
+			}
+
+			decoded, err := decodeTMXData(encoded, 3, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(decoded, data) {
+				t.Errorf("round trip = %v, want %v", decoded, data)
+			}
+		})
+	}
+}
+
+func TestEncodeLayer(t *testing.T) {
+	stg := xsbStage(t, "######", "#@$ .#", "######")
+
+	encoded, err := stg.EncodeLayer(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded.Encoding != EncodingCSV {
+		t.Errorf("EncodeLayer() encoding = %q, want csv", encoded.Encoding)
+	}
+
+	decoded, err := decodeTMXData(encoded, stg.TMX.Width, stg.TMX.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, stg.Layers[0]) {
+		t.Errorf("EncodeLayer() = %v, want %v", decoded, stg.Layers[0])
+	}
+}
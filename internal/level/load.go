@@ -0,0 +1,156 @@
+package level
+
+import (
+	"encoding/xml"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LoadStages reads every TMX and XSB stage in dir ordered by name, without validating them.
+func LoadStages(fsys fs.FS, dir string) ([]Stage, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read directory")
+	}
+
+	res := make([]Stage, 0)
+	tilesets := make(tilesetCache)
+
+	for _, entry := range entries {
+		if !isStageFile(entry.Name()) {
+			continue
+		}
+
+		stages, err := loadStageFile(fsys, path.Join(dir, entry.Name()), tilesets)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error on load '%s'", entry.Name())
+		}
+
+		res = append(res, stages...)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, _ := strconv.Atoi(res[i].Name)
+		b, _ := strconv.Atoi(res[j].Name)
+
+		return a < b
+	})
+
+	return res, nil
+}
+
+func isStageFile(filename string) bool {
+	switch path.Ext(filename) {
+	case ".tmx", ".xsb", ".sok", ".txt":
+		return true
+	default:
+		return false
+	}
+}
+
+// loadStageFile reads the stage of a TMX file or the levels of an XSB file, named after the file.
+func loadStageFile(fsys fs.FS, filename string, tilesets tilesetCache) ([]Stage, error) {
+	ext := path.Ext(filename)
+	name := strings.TrimSuffix(path.Base(filename), ext)
+
+	switch ext {
+	case ".tmx":
+		stg, err := loadTMX(fsys, filename, name, tilesets)
+		if err != nil {
+			return nil, errors.Wrap(err, "error on load tmx file")
+		}
+
+		return []Stage{stg}, nil
+	case ".xsb", ".sok", ".txt":
+		stages, err := loadXSB(fsys, filename, name)
+		if err != nil {
+			return nil, errors.Wrap(err, "error on load xsb file")
+		}
+
+		return stages, nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownStageFile, "'%s'", ext)
+	}
+}
+
+func loadTMX(fsys fs.FS, filename, name string, tilesets tilesetCache) (Stage, error) {
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return Stage{}, errors.Wrap(err, "error on open file")
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var tmx TMX
+
+	err = xml.NewDecoder(file).Decode(&tmx)
+	if err != nil {
+		return Stage{}, errors.Wrap(err, "error on decode tmx file")
+	}
+
+	tiles, err := loadTiles(fsys, filename, tmx, tilesets)
+	if err != nil {
+		return Stage{}, errors.Wrap(err, "error on load tiles")
+	}
+
+	layers := make([][][]int, len(tmx.Layers))
+	for i := range tmx.Layers {
+		layers[i], err = decodeTMXData(tmx.Layers[i].Data, tmx.Width, tmx.Height)
+		if err != nil {
+			return Stage{}, errors.Wrapf(err, "error on decode layer '%s'", tmx.Layers[i].Name)
+		}
+	}
+
+	spawns, err := loadSpawns(tmx, tiles)
+	if err != nil {
+		return Stage{}, errors.Wrap(err, "error on load spawns")
+	}
+
+	stg, err := Stage{
+		Name:       name,
+		Pack:       "",
+		Layers:     layers,
+		Spawns:     spawns,
+		Tiles:      tiles,
+		TMX:        tmx,
+		Title:      "",
+		Author:     "",
+		Difficulty: "",
+		Hint:       "",
+		Theme:      0,
+		ParMoves:   0,
+		ParPushes:  0,
+	}.withProperties(tmx.Properties)
+	if err != nil {
+		return Stage{}, errors.Wrap(err, "error on read map properties")
+	}
+
+	if stg.Theme == 0 {
+		stg.Theme = stg.floorTheme()
+	}
+
+	stg.pictureSpawns()
+
+	return stg, nil
+}
+
+func loadXSB(fsys fs.FS, filename, name string) ([]Stage, error) {
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on open file")
+	}
+
+	defer func() { _ = file.Close() }()
+
+	stages, err := ParseXSB(name, file)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on parse xsb file")
+	}
+
+	return stages, nil
+}
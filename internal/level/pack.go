@@ -0,0 +1,212 @@
+package level
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrEmptySection     = errors.New("section has no stages")
+	ErrUnknownStageFile = errors.New("unknown stage file type")
+)
+
+// PackManifest describes a level pack. Stages of each section are file names relative to the manifest,
+// an XSB collection adds all of its levels.
+type PackManifest struct {
+	Title    string            `json:"title" yaml:"title"`
+	Author   string            `json:"author" yaml:"author"`
+	License  string            `json:"license" yaml:"license"`
+	Credits  []string          `json:"credits" yaml:"credits"`
+	Sections []SectionManifest `json:"sections" yaml:"sections"`
+}
+
+type SectionManifest struct {
+	Title  string   `json:"title" yaml:"title"`
+	Stages []string `json:"stages" yaml:"stages"`
+}
+
+// manifestNames returns the pack manifest files looked up next to the stages, in order.
+func manifestNames() []string {
+	return []string{"pack.json", "pack.yaml", "pack.yml"}
+}
+
+// Pack is a set of stages in play order, split into sections.
+// ID keeps progress of packs with the same stage names apart, it's empty for the built-in pack.
+type Pack struct {
+	ID       string
+	Title    string
+	Author   string
+	License  string
+	Credits  []string
+	Sections []Section
+	Stages   []Stage
+}
+
+// Section is a named run of stages of a pack, from Start to before End.
+type Section struct {
+	Title      string
+	Start, End int
+}
+
+// Label is how the pack is named on screen, its title or ID, or the game name for the built-in pack.
+func (pack Pack) Label() string {
+	switch {
+	case pack.Title != "":
+		return pack.Title
+	case pack.ID != "":
+		return pack.ID
+	default:
+		return "SHOVE IT"
+	}
+}
+
+// Filter returns the pack with only the stages keep accepts, in the same order.
+// Sections left without stages are dropped.
+func (pack Pack) Filter(keep func(stg Stage) bool) Pack {
+	res := pack
+	res.Stages = make([]Stage, 0, len(pack.Stages))
+	res.Sections = make([]Section, 0, len(pack.Sections))
+
+	for _, section := range pack.Sections {
+		start := len(res.Stages)
+
+		for i := section.Start; i < section.End; i++ {
+			if keep(pack.Stages[i]) {
+				res.Stages = append(res.Stages, pack.Stages[i])
+			}
+		}
+
+		if len(res.Stages) > start {
+			res.Sections = append(res.Sections, Section{Title: section.Title, Start: start, End: len(res.Stages)})
+		}
+	}
+
+	return res
+}
+
+// singlePack makes an untitled pack of a single section.
+func singlePack(stages []Stage) Pack {
+	return Pack{
+		ID:       "",
+		Title:    "",
+		Author:   "",
+		License:  "",
+		Credits:  nil,
+		Sections: []Section{{Title: "", Start: 0, End: len(stages)}},
+		Stages:   stages,
+	}
+}
+
+// LoadPack reads the stages in dir ordered by its manifest. Without one, all stages of dir
+// make a single untitled section in the order of LoadStages.
+func LoadPack(fsys fs.FS, dir string) (Pack, error) {
+	for _, name := range manifestNames() {
+		filename := path.Join(dir, name)
+
+		if _, err := fs.Stat(fsys, filename); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		pack, err := loadManifestPack(fsys, filename)
+		if err != nil {
+			return Pack{}, errors.Wrapf(err, "error on load manifest '%s'", name)
+		}
+
+		return pack, nil
+	}
+
+	stages, err := LoadStages(fsys, dir)
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on load stages")
+	}
+
+	return singlePack(stages), nil
+}
+
+// LoadPackFile reads a pack from a manifest, or from a single TMX or XSB file.
+func LoadPackFile(fsys fs.FS, filename string) (Pack, error) {
+	if IsManifest(filename) {
+		pack, err := loadManifestPack(fsys, filename)
+		if err != nil {
+			return Pack{}, errors.Wrap(err, "error on load manifest")
+		}
+
+		return pack, nil
+	}
+
+	stages, err := loadStageFile(fsys, filename, make(tilesetCache))
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on load stage file")
+	}
+
+	return singlePack(stages), nil
+}
+
+// IsManifest reports whether filename is named as a pack manifest.
+func IsManifest(filename string) bool {
+	for _, name := range manifestNames() {
+		if path.Base(filename) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadManifestPack reads a manifest and the stages it lists, relative to the manifest.
+func loadManifestPack(fsys fs.FS, filename string) (Pack, error) {
+	b, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on read file")
+	}
+
+	var manifest PackManifest
+
+	if path.Ext(filename) == ".json" {
+		err = json.Unmarshal(b, &manifest)
+	} else {
+		err = yaml.Unmarshal(b, &manifest)
+	}
+
+	if err != nil {
+		return Pack{}, errors.Wrap(err, "error on decode manifest")
+	}
+
+	pack := Pack{
+		ID:       "",
+		Title:    manifest.Title,
+		Author:   manifest.Author,
+		License:  manifest.License,
+		Credits:  manifest.Credits,
+		Sections: make([]Section, 0, len(manifest.Sections)),
+		Stages:   make([]Stage, 0),
+	}
+
+	dir := path.Dir(filename)
+	tilesets := make(tilesetCache)
+
+	for _, section := range manifest.Sections {
+		if len(section.Stages) == 0 {
+			return Pack{}, errors.Wrapf(ErrEmptySection, "'%s'", section.Title)
+		}
+
+		start := len(pack.Stages)
+
+		for _, name := range section.Stages {
+			stages, err := loadStageFile(fsys, path.Join(dir, name), tilesets)
+			if err != nil {
+				return Pack{}, errors.Wrapf(err, "error on load '%s'", name)
+			}
+
+			pack.Stages = append(pack.Stages, stages...)
+		}
+
+		pack.Sections = append(pack.Sections, Section{Title: section.Title, Start: start, End: len(pack.Stages)})
+	}
+
+	return pack, nil
+}
@@ -0,0 +1,258 @@
+package level
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+)
+
+func TestPackFilter(t *testing.T) {
+	stages := make([]Stage, 5)
+	for i := range stages {
+		stages[i].Name = string(rune('a' + i))
+	}
+
+	pack := singlePack(stages)
+	pack.Sections = []Section{{Title: "one", Start: 0, End: 2}, {Title: "two", Start: 2, End: 3}, {Title: "three", Start: 3, End: 5}}
+
+	tests := []struct {
+		name     string
+		skip     string
+		stages   string
+		sections []Section
+	}{
+		{
+			name:     "keep all",
+			skip:     "",
+			stages:   "abcde",
+			sections: []Section{{Title: "one", Start: 0, End: 2}, {Title: "two", Start: 2, End: 3}, {Title: "three", Start: 3, End: 5}},
+		},
+		{
+			name:     "first of a section",
+			skip:     "a",
+			stages:   "bcde",
+			sections: []Section{{Title: "one", Start: 0, End: 1}, {Title: "two", Start: 1, End: 2}, {Title: "three", Start: 2, End: 4}},
+		},
+		{
+			name:     "whole section",
+			skip:     "c",
+			stages:   "abde",
+			sections: []Section{{Title: "one", Start: 0, End: 2}, {Title: "three", Start: 2, End: 4}},
+		},
+		{
+			name:     "everything",
+			skip:     "abcde",
+			stages:   "",
+			sections: []Section{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := pack.Filter(func(stg Stage) bool {
+				for _, c := range tt.skip {
+					if stg.Name == string(c) {
+						return false
+					}
+				}
+
+				return true
+			})
+
+			names := ""
+			for _, stg := range res.Stages {
+				names += stg.Name
+			}
+
+			if names != tt.stages {
+				t.Errorf("stages = %q, want %q", names, tt.stages)
+			}
+
+			if !reflect.DeepEqual(res.Sections, tt.sections) {
+				t.Errorf("sections = %v, want %v", res.Sections, tt.sections)
+			}
+		})
+	}
+
+	if len(pack.Stages) != len(stages) {
+		t.Error("Filter() changed the pack")
+	}
+}
+
+func packFS(files map[string]string) fstest.MapFS {
+	level := "#####\n#@$.#\n#####\n"
+
+	fsys := fstest.MapFS{
+		"pack/1.xsb":   &fstest.MapFile{Data: []byte(level)},
+		"pack/2.xsb":   &fstest.MapFile{Data: []byte(level)},
+		"pack/10.xsb":  &fstest.MapFile{Data: []byte(level)},
+		"pack/two.xsb": &fstest.MapFile{Data: []byte(level + "\n" + level)},
+	}
+
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+
+	return fsys
+}
+
+func stageNames(pack Pack) string {
+	names := make([]string, len(pack.Stages))
+	for i := range pack.Stages {
+		names[i] = pack.Stages[i].Name
+	}
+
+	return strings.Join(names, ",")
+}
+
+func TestLoadPack(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		title    string
+		stages   string
+		sections []Section
+		err      error
+	}{
+		{
+			name:     "no manifest",
+			files:    nil,
+			title:    "",
+			stages:   "two 1,two 2,1,2,10",
+			sections: []Section{{Title: "", Start: 0, End: 5}},
+			err:      nil,
+		},
+		{
+			name:     "json",
+			files:    map[string]string{"pack/pack.json": `{"title": "Test", "sections": [{"title": "A", "stages": ["10.xsb", "1.xsb"]}, {"title": "B", "stages": ["two.xsb"]}]}`},
+			title:    "Test",
+			stages:   "10,1,two 1,two 2",
+			sections: []Section{{Title: "A", Start: 0, End: 2}, {Title: "B", Start: 2, End: 4}},
+			err:      nil,
+		},
+		{
+			name:     "yaml",
+			files:    map[string]string{"pack/pack.yaml": "title: Test\nsections:\n  - title: A\n    stages: [2.xsb]\n"},
+			title:    "Test",
+			stages:   "2",
+			sections: []Section{{Title: "A", Start: 0, End: 1}},
+			err:      nil,
+		},
+		{
+			name: "json before yaml",
+			files: map[string]string{
+				"pack/pack.json": `{"title": "JSON", "sections": [{"title": "A", "stages": ["1.xsb"]}]}`,
+				"pack/pack.yml":  "title: YAML\n",
+			},
+			title:    "JSON",
+			stages:   "1",
+			sections: []Section{{Title: "A", Start: 0, End: 1}},
+			err:      nil,
+		},
+		{
+			name:     "empty section",
+			files:    map[string]string{"pack/pack.yml": "sections:\n  - title: A\n"},
+			title:    "",
+			stages:   "",
+			sections: nil,
+			err:      ErrEmptySection,
+		},
+		{
+			name:     "unknown stage file",
+			files:    map[string]string{"pack/pack.json": `{"sections": [{"title": "A", "stages": ["1.png"]}]}`},
+			title:    "",
+			stages:   "",
+			sections: nil,
+			err:      ErrUnknownStageFile,
+		},
+		{
+			name:     "missing stage file",
+			files:    map[string]string{"pack/pack.json": `{"sections": [{"title": "A", "stages": ["3.xsb"]}]}`},
+			title:    "",
+			stages:   "",
+			sections: nil,
+			err:      fs.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack, err := LoadPack(packFS(tt.files), "pack")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("LoadPack() error = %v, want %v", err, tt.err)
+			}
+
+			if pack.Title != tt.title {
+				t.Errorf("title = %q, want %q", pack.Title, tt.title)
+			}
+
+			if names := stageNames(pack); names != tt.stages {
+				t.Errorf("stages = %q, want %q", names, tt.stages)
+			}
+
+			if !reflect.DeepEqual(pack.Sections, tt.sections) {
+				t.Errorf("sections = %v, want %v", pack.Sections, tt.sections)
+			}
+		})
+	}
+}
+
+func TestLoadPackFile(t *testing.T) {
+	fsys := packFS(map[string]string{"pack/pack.yml": "title: Test\nauthor: Someone\nlicense: CC0\ncredits: [Tester]\nsections:\n  - title: A\n    stages: [1.xsb]\n"})
+
+	tests := []struct {
+		name     string
+		filename string
+		want     Pack
+	}{
+		{
+			name:     "manifest",
+			filename: "pack/pack.yml",
+			want:     Pack{ID: "", Title: "Test", Author: "Someone", License: "CC0", Credits: []string{"Tester"}, Sections: []Section{{Title: "A", Start: 0, End: 1}}, Stages: nil},
+		},
+		{
+			name:     "collection",
+			filename: "pack/two.xsb",
+			want:     Pack{ID: "", Title: "", Author: "", License: "", Credits: nil, Sections: []Section{{Title: "", Start: 0, End: 2}}, Stages: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack, err := LoadPackFile(fsys, tt.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pack.Stages = nil
+
+			if !reflect.DeepEqual(pack, tt.want) {
+				t.Errorf("LoadPackFile() = %+v, want %+v", pack, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsManifest(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     bool
+	}{
+		{filename: "pack.json", want: true},
+		{filename: "mods/pack/pack.yaml", want: true},
+		{filename: "pack.yml", want: true},
+		{filename: "pack.toml", want: false},
+		{filename: "my-pack.json", want: false},
+		{filename: "pack.json/1.tmx", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsManifest(tt.filename); got != tt.want {
+			t.Errorf("IsManifest(%q) = %v, want %v", tt.filename, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package level
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Object types read from object layers, from the type or, since Tiled 1.9, the class of an object.
+const (
+	ObjectPlayer = "player"
+	ObjectBox    = "box"
+	ObjectGoal   = "goal"
+)
+
+type TMXObjectGroup struct {
+	Name    string      `xml:"name,attr"`
+	Objects []TMXObject `xml:"object"`
+}
+
+type TMXObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	GID        int           `xml:"gid,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Properties []TMXProperty `xml:"properties>property"`
+}
+
+// defaultBoxSprite draws a box placed by an object without a tile.
+const defaultBoxSprite = "box1"
+
+// Spawn is a tile placed by an object on top of the tile layers.
+type Spawn struct {
+	I, J int
+	Tile Tile
+}
+
+// loadSpawns turns objects of the map into spawns. A tile object starts with the meaning of its tile,
+// then its type and custom properties add to it. Objects of other types are left out.
+func loadSpawns(tmx TMX, tiles map[int]Tile) ([]Spawn, error) {
+	res := make([]Spawn, 0)
+
+	for _, group := range tmx.ObjectGroups {
+		for _, obj := range group.Objects {
+			tile := tiles[obj.GID&tileGIDMask]
+
+			kind := obj.Type
+			if kind == "" {
+				kind = obj.Class
+			}
+
+			switch kind {
+			case ObjectPlayer:
+				tile.Player = true
+			case ObjectBox:
+				tile.Box = true
+			case ObjectGoal:
+				tile.Goal = true
+			default:
+				if obj.GID == 0 {
+					continue
+				}
+			}
+
+			tile, err := tile.withProperties(obj.Properties)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on read object %d", obj.ID)
+			}
+
+			// tile objects are anchored at their bottom left corner
+			i := int(math.Floor(obj.X / float64(tmx.TileWidth)))
+			j := int(math.Floor(obj.Y / float64(tmx.TileHeight)))
+
+			if obj.GID != 0 {
+				j = int(math.Ceil(obj.Y/float64(tmx.TileHeight))) - 1
+			}
+
+			res = append(res, Spawn{I: i, J: j, Tile: tile})
+		}
+	}
+
+	return res, nil
+}
+
+// pictureSpawns gives spawns of objects without a tile a picture, the flag of their theme for a goal
+// and the first box for a box. A player is drawn by the game.
+func (stg Stage) pictureSpawns() {
+	for k := range stg.Spawns {
+		tile := &stg.Spawns[k].Tile
+		if tile.Sprite != "" || tile.Image != nil {
+			continue
+		}
+
+		switch {
+		case tile.Box:
+			tile.Sprite = defaultBoxSprite
+		case tile.Goal && !tile.Player:
+			theme := stg.Theme
+			if tile.Theme != 0 {
+				theme = tile.Theme
+			}
+
+			_, flag := stg.ThemeTiles(theme)
+			tile.Sprite, tile.Image = flag.Sprite, flag.Image
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package level
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSpawnPictures(t *testing.T) {
+	stages, err := loadStageFile(os.DirFS("../.."), "internal/level/testdata/spawn.tmx", make(tilesetCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stg := stages[0]
+
+	if err := stg.Validate(); err != nil {
+		t.Fatalf("stage isn't valid: %v", err)
+	}
+
+	_, flag := stg.ThemeTiles(stg.Theme)
+	if flag.Image == nil {
+		t.Fatal("theme has no flag picture")
+	}
+
+	tests := []struct {
+		name   string
+		i, j   int
+		sprite string
+		flag   bool
+	}{
+		{name: "box", i: 2, j: 2, sprite: defaultBoxSprite, flag: false},
+		{name: "box on top row", i: 3, j: 1, sprite: defaultBoxSprite, flag: false},
+		{name: "goal", i: 4, j: 2, sprite: "", flag: true},
+		{name: "goal on top row", i: 5, j: 1, sprite: "", flag: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cell := stg.Cell(tt.i, tt.j)
+			top := cell[len(cell)-1]
+
+			if top.Sprite != tt.sprite {
+				t.Errorf("sprite = %q, want %q", top.Sprite, tt.sprite)
+			}
+
+			if got := top.Image == flag.Image; got != tt.flag {
+				t.Errorf("flag picture = %v, want %v", got, tt.flag)
+			}
+		})
+	}
+}
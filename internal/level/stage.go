@@ -0,0 +1,206 @@
+package level
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
+	"github.com/pkg/errors"
+)
+
+// TileSize is the width and height of the shipped tiles in pixels.
+const TileSize = 24
+
+// Items are the gids of the shipped tileset.tsx, which XSB levels are mapped onto.
+// What a tile means comes from Stage.Tiles.
+const (
+	ItemBackground1 = iota + 1
+	ItemBackground2
+	ItemBackground3
+	ItemBackground4
+	ItemBackground5
+	ItemWall1 = iota + 4
+	ItemWall2
+	ItemWall3
+	ItemWall4
+	ItemTile1 = iota + 8
+	ItemTile2
+	ItemTile3
+	ItemTileFlagged1 = iota + 13
+	ItemTileFlagged2
+	ItemTileFlagged3
+	ItemPlayer1 = iota + 18
+	ItemPlayer2
+	ItemPlayer3
+	ItemBox1 = iota + 23
+	ItemBox2
+	ItemBox3
+	ItemBox4
+	ItemBox5
+	ItemBoxDone1 = iota + 26
+	ItemBoxDone2
+	ItemBoxDone3
+	ItemBoxDone4
+	ItemBoxDone5
+)
+
+// Player on a flagged tile has no image in the tile set and is only produced by imported levels.
+const (
+	ItemPlayerFlagged1 = iota + 36
+	ItemPlayerFlagged2
+	ItemPlayerFlagged3
+)
+
+// Stage is a level. Layers are rows of gids from bottom to top, and Tiles maps gids to their meaning.
+// Spawns are placed on top of the layers. Pack is the ID of the pack the stage is played in.
+// Par moves and pushes are zero when unknown, and the other metadata is empty.
+type Stage struct {
+	Name       string
+	Pack       string
+	Layers     [][][]int
+	Spawns     []Spawn
+	Tiles      map[int]Tile
+	TMX        TMX
+	Title      string
+	Author     string
+	Difficulty string
+	Hint       string
+	Theme      int
+	ParMoves   int
+	ParPushes  int
+}
+
+type TMX struct {
+	XMLName      xml.Name         `xml:"map"`
+	Width        int              `xml:"width,attr"`
+	Height       int              `xml:"height,attr"`
+	TileWidth    int              `xml:"tilewidth,attr"`
+	TileHeight   int              `xml:"tileheight,attr"`
+	Tilesets     []TMXTileset     `xml:"tileset"`
+	Layers       []TMXLayer       `xml:"layer"`
+	ObjectGroups []TMXObjectGroup `xml:"objectgroup"`
+	Properties   []TMXProperty    `xml:"properties>property"`
+}
+
+type TMXLayer struct {
+	Name string  `xml:"name,attr"`
+	Data TMXData `xml:"data"`
+}
+
+// Key identifies the stage in saved progress and replays.
+func (stg Stage) Key() string {
+	if stg.Pack == "" {
+		return stg.Name
+	}
+
+	return stg.Pack + "/" + stg.Name
+}
+
+// DisplayName is the title of the stage, or its name when it has none.
+func (stg Stage) DisplayName() string {
+	if stg.Title != "" {
+		return stg.Title
+	}
+
+	return stg.Name
+}
+
+// Label is how the stage is introduced on screen, its title or "STAGE" and its name.
+func (stg Stage) Label() string {
+	if stg.Title != "" {
+		return stg.Title
+	}
+
+	return "STAGE " + stg.Name
+}
+
+// withProperties returns stage with its metadata read from map properties.
+func (stg Stage) withProperties(props []TMXProperty) (_ Stage, err error) {
+	for _, prop := range props {
+		switch prop.Name {
+		case "title":
+			stg.Title = prop.String()
+		case "author":
+			stg.Author = prop.String()
+		case "difficulty":
+			stg.Difficulty = prop.String()
+		case "hint":
+			// the message line is a single row
+			stg.Hint = strings.Join(strings.Fields(prop.String()), " ")
+		case "theme":
+			stg.Theme, err = strconv.Atoi(prop.Value)
+		case "parMoves":
+			stg.ParMoves, err = strconv.Atoi(prop.Value)
+		case "parPushes":
+			stg.ParPushes, err = strconv.Atoi(prop.Value)
+		}
+
+		if err != nil {
+			return Stage{}, errors.Wrapf(err, "error on parse property '%s'", prop.Name)
+		}
+	}
+
+	return stg, nil
+}
+
+func (stg Stage) Width() int {
+	return stg.TMX.Width * TileSize
+}
+
+func (stg Stage) Height() int {
+	return stg.TMX.Height * TileSize
+}
+
+// ValueAt returns the gid at (i, j) of a layer or 0, as empty, outside of it.
+func (stg Stage) ValueAt(layer, i, j int) int {
+	data := stg.Layers[layer]
+	if j < 0 || j >= len(data) || i < 0 || i >= len(data[j]) {
+		return 0
+	}
+
+	return data[j][i]
+}
+
+func (stg Stage) IsWall(i, j int) bool {
+	return stg.Tile(i, j).Wall
+}
+
+func (stg Stage) IsFlag(i, j int) bool {
+	return stg.Tile(i, j).Goal
+}
+
+func (stg Stage) IsPlayer(i, j int) bool {
+	return stg.Tile(i, j).Player
+}
+
+func (stg Stage) IsBox(i, j int) bool {
+	return stg.Tile(i, j).Box
+}
+
+// NewState creates the rules state of stage at its starting position.
+// Boxes are indexed in row-major order.
+func (stg Stage) NewState() *sokoban.State {
+	state := sokoban.New(stg.TMX.Width, stg.TMX.Height)
+
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			pos := sokoban.Position{I: i, J: j}
+
+			switch {
+			case stg.IsWall(i, j):
+				state.SetWall(pos)
+			case stg.IsPlayer(i, j):
+				state.SetPlayer(pos)
+			case stg.IsBox(i, j):
+				state.AddBox(pos)
+			}
+
+			if stg.IsFlag(i, j) {
+				state.SetGoal(pos)
+			}
+		}
+	}
+
+	return state
+}
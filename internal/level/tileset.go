@@ -0,0 +1,476 @@
+package level
+
+import (
+	"encoding/xml"
+	"image"
+	_ "image/png" // tile set images
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Tiled keeps flip flags in the highest bits of a gid.
+const tileGIDMask = 0x1fffffff
+
+const defaultTheme = 1
+
+var (
+	ErrNoTileSize       = errors.New("tile set has no tile size")
+	ErrTileOutsideImage = errors.New("tile is outside of tile set image")
+	ErrNoColumns        = errors.New("tile set image is narrower than a tile")
+)
+
+// Tile is what a tile of a tile set means in a stage, read from its custom properties.
+// Theme picks the floor and flag drawn under a player or box, zero for the stage theme.
+// Image is the picture of the tile in its tile set, and a box can have other pictures while it's on
+// or off a flag. Sprite names a sprite of the atlas drawn instead of Image.
+type Tile struct {
+	Wall    bool
+	Floor   bool
+	Goal    bool
+	Box     bool
+	Player  bool
+	Theme   int
+	Sprite  string
+	Image   *TileImage
+	OnGoal  *TileImage
+	OffGoal *TileImage
+}
+
+// TileImage is the part of a decoded tile set image a tile is drawn with.
+// Source is the image file, which tells images of different tile sets apart.
+type TileImage struct {
+	Source string
+	Image  image.Image
+	Rect   image.Rectangle
+}
+
+// TMXTileset is a tile set of a map, either a reference to a tsx file or inline.
+type TMXTileset struct {
+	FirstGID int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+	TSX
+}
+
+// TSX is a Tiled tile set. Its tiles are cut from Image in rows of Columns, or each tile has an image of its own.
+type TSX struct {
+	Name       string    `xml:"name,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+	Columns    int       `xml:"columns,attr"`
+	Spacing    int       `xml:"spacing,attr"`
+	Margin     int       `xml:"margin,attr"`
+	Image      *TSXImage `xml:"image"`
+	Tiles      []TSXTile `xml:"tile"`
+}
+
+type TSXImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type TSXTile struct {
+	ID         int           `xml:"id,attr"`
+	Image      *TSXImage     `xml:"image"`
+	Properties []TMXProperty `xml:"properties>property"`
+}
+
+type TMXProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+// String returns the value of the property, which Tiled writes as text for multiline strings.
+func (prop TMXProperty) String() string {
+	if prop.Value == "" {
+		return strings.TrimSpace(prop.Text)
+	}
+
+	return prop.Value
+}
+
+// builtinTiles are the tiles of the shipped tileset.tsx by gid, used by stages imported from XSB.
+// They're drawn with the sprites of the shipped atlas.
+func builtinTiles() map[int]Tile {
+	res := make(map[int]Tile)
+
+	walls := []string{"wall1", "wall2", "wall3", "wall4"}
+	for i := range walls {
+		res[ItemWall1+i] = Tile{Wall: true, Floor: false, Goal: false, Box: false, Player: false, Theme: 0, Sprite: walls[i], Image: nil, OnGoal: nil, OffGoal: nil}
+	}
+
+	backgrounds := []string{"background1", "background2", "background3", "background4", "background5"}
+	for i := range backgrounds {
+		res[ItemBackground1+i] = Tile{Wall: false, Floor: false, Goal: false, Box: false, Player: false, Theme: 0, Sprite: backgrounds[i], Image: nil, OnGoal: nil, OffGoal: nil}
+	}
+
+	for theme := 1; theme <= 3; theme++ {
+		res[ItemTile1+theme-1] = Tile{Wall: false, Floor: true, Goal: false, Box: false, Player: false, Theme: theme, Sprite: "tile" + strconv.Itoa(theme), Image: nil, OnGoal: nil, OffGoal: nil}
+		res[ItemTileFlagged1+theme-1] = Tile{Wall: false, Floor: false, Goal: true, Box: false, Player: false, Theme: theme, Sprite: "flag" + strconv.Itoa(theme), Image: nil, OnGoal: nil, OffGoal: nil}
+		res[ItemPlayer1+theme-1] = Tile{Wall: false, Floor: false, Goal: false, Box: false, Player: true, Theme: theme, Sprite: "", Image: nil, OnGoal: nil, OffGoal: nil}
+		res[ItemPlayerFlagged1+theme-1] = Tile{Wall: false, Floor: false, Goal: true, Box: false, Player: true, Theme: theme, Sprite: "", Image: nil, OnGoal: nil, OffGoal: nil}
+	}
+
+	boxes := []string{"box1", "box2", "box3", "box4", "box5"}
+	for i := range boxes {
+		res[ItemBox1+i] = Tile{Wall: false, Floor: false, Goal: false, Box: true, Player: false, Theme: 0, Sprite: boxes[i], Image: nil, OnGoal: nil, OffGoal: nil}
+		res[ItemBoxDone1+i] = Tile{Wall: false, Floor: false, Goal: true, Box: true, Player: false, Theme: 0, Sprite: boxes[i], Image: nil, OnGoal: nil, OffGoal: nil}
+	}
+
+	return res
+}
+
+// withProperties returns tile with its meaning changed by custom properties.
+func (tile Tile) withProperties(props []TMXProperty) (_ Tile, err error) {
+	for _, prop := range props {
+		switch prop.Name {
+		case "wall":
+			tile.Wall, err = strconv.ParseBool(prop.Value)
+		case "floor":
+			tile.Floor, err = strconv.ParseBool(prop.Value)
+		case "goal":
+			tile.Goal, err = strconv.ParseBool(prop.Value)
+		case "box":
+			tile.Box, err = strconv.ParseBool(prop.Value)
+		case "player":
+			tile.Player, err = strconv.ParseBool(prop.Value)
+		case "theme":
+			tile.Theme, err = strconv.Atoi(prop.Value)
+		case "sprite":
+			tile.Sprite = prop.Value
+		}
+
+		if err != nil {
+			return Tile{}, errors.Wrapf(err, "error on parse property '%s'", prop.Name)
+		}
+	}
+
+	return tile, nil
+}
+
+func loadTSX(fsys fs.FS, filename string) (TSX, error) {
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return TSX{}, errors.Wrap(err, "error on open file")
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var tsx TSX
+
+	err = xml.NewDecoder(file).Decode(&tsx)
+	if err != nil {
+		return TSX{}, errors.Wrap(err, "error on decode tsx file")
+	}
+
+	return tsx, nil
+}
+
+func loadImage(fsys fs.FS, filename string) (image.Image, error) {
+	file, err := fsys.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on open file")
+	}
+
+	defer func() { _ = file.Close() }()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on decode image")
+	}
+
+	return img, nil
+}
+
+// tilesetCache keeps the tiles of tsx files by id, since a pack usually shares one.
+type tilesetCache map[string]map[int]Tile
+
+func (cache tilesetCache) load(fsys fs.FS, filename string) (map[int]Tile, error) {
+	if tiles, ok := cache[filename]; ok {
+		return tiles, nil
+	}
+
+	tsx, err := loadTSX(fsys, filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on load tsx")
+	}
+
+	tiles, err := readTileset(fsys, path.Dir(filename), tsx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on read tileset")
+	}
+
+	cache[filename] = tiles
+
+	return tiles, nil
+}
+
+// loadTiles resolves the tiles of every tile set of a map by gid. Tile set files are relative to the map file.
+func loadTiles(fsys fs.FS, filename string, tmx TMX, cache tilesetCache) (map[int]Tile, error) {
+	res := make(map[int]Tile)
+
+	for _, tileset := range tmx.Tilesets {
+		var (
+			tiles map[int]Tile
+			err   error
+		)
+
+		if tileset.Source != "" {
+			tiles, err = cache.load(fsys, path.Join(path.Dir(filename), tileset.Source))
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on load tileset '%s'", tileset.Source)
+			}
+		} else {
+			tiles, err = readTileset(fsys, path.Dir(filename), tileset.TSX)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on read tileset '%s'", tileset.Name)
+			}
+		}
+
+		for id, tile := range tiles {
+			res[tileset.FirstGID+id] = tile
+		}
+	}
+
+	return res, nil
+}
+
+// readTileset resolves every tile of a tile set by id, with images relative to dir.
+// Every tile of the tile set image is there, the ones without properties are decoration.
+func readTileset(fsys fs.FS, dir string, tsx TSX) (map[int]Tile, error) {
+	res := make(map[int]Tile)
+
+	if tsx.Image != nil {
+		images, err := cutTiles(fsys, dir, tsx)
+		if err != nil {
+			return nil, errors.Wrap(err, "error on cut tiles")
+		}
+
+		for id := range images {
+			res[id] = Tile{Wall: false, Floor: false, Goal: false, Box: false, Player: false, Theme: 0, Sprite: "", Image: images[id], OnGoal: nil, OffGoal: nil}
+		}
+	}
+
+	for _, t := range tsx.Tiles {
+		tile := res[t.ID]
+
+		if t.Image != nil {
+			source := path.Join(dir, t.Image.Source)
+
+			img, err := loadImage(fsys, source)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on load image of tile %d", t.ID)
+			}
+
+			tile.Image = &TileImage{Source: source, Image: img, Rect: img.Bounds()}
+		}
+
+		tile, err := tile.withProperties(t.Properties)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error on read tile %d", t.ID)
+		}
+
+		res[t.ID] = tile
+	}
+
+	// pictures of boxes on and off a flag are other tiles of the set, resolved once every tile is read
+	for _, t := range tsx.Tiles {
+		tile := res[t.ID]
+
+		for _, prop := range t.Properties {
+			if prop.Name != "onGoal" && prop.Name != "offGoal" {
+				continue
+			}
+
+			id, err := strconv.Atoi(prop.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error on parse property '%s' of tile %d", prop.Name, t.ID)
+			}
+
+			other, ok := res[id]
+			if !ok || other.Image == nil {
+				return nil, errors.Wrapf(ErrUnknownTile, "%d in property '%s' of tile %d", id, prop.Name, t.ID)
+			}
+
+			if prop.Name == "onGoal" {
+				tile.OnGoal = other.Image
+			} else {
+				tile.OffGoal = other.Image
+			}
+		}
+
+		res[t.ID] = tile
+	}
+
+	return res, nil
+}
+
+// cutTiles cuts the tile set image into tiles by id, left to right and top to bottom.
+// Older tile sets without columns or tile count get them from the image size.
+func cutTiles(fsys fs.FS, dir string, tsx TSX) (map[int]*TileImage, error) {
+	if tsx.TileWidth <= 0 || tsx.TileHeight <= 0 {
+		return nil, ErrNoTileSize
+	}
+
+	source := path.Join(dir, tsx.Image.Source)
+
+	img, err := loadImage(fsys, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "error on load image")
+	}
+
+	bounds := img.Bounds()
+
+	columns := tsx.Columns
+	if columns == 0 {
+		columns = (bounds.Dx() - 2*tsx.Margin + tsx.Spacing) / (tsx.TileWidth + tsx.Spacing)
+	}
+
+	if columns <= 0 {
+		return nil, errors.Wrapf(ErrNoColumns, "%d pixels wide for tiles of %d", bounds.Dx(), tsx.TileWidth)
+	}
+
+	count := tsx.TileCount
+	if count == 0 {
+		count = columns * ((bounds.Dy() - 2*tsx.Margin + tsx.Spacing) / (tsx.TileHeight + tsx.Spacing))
+	}
+
+	res := make(map[int]*TileImage, count)
+
+	for id := 0; id < count; id++ {
+		x := tsx.Margin + id%columns*(tsx.TileWidth+tsx.Spacing)
+		y := tsx.Margin + id/columns*(tsx.TileHeight+tsx.Spacing)
+		rect := image.Rect(x, y, x+tsx.TileWidth, y+tsx.TileHeight).Add(bounds.Min)
+
+		if !rect.In(bounds) {
+			return nil, errors.Wrapf(ErrTileOutsideImage, "tile %d", id)
+		}
+
+		res[id] = &TileImage{Source: source, Image: img, Rect: rect}
+	}
+
+	return res, nil
+}
+
+// Cell returns the tiles at (i, j) from bottom to top, layers first and then spawns.
+// Empty and unknown gids are left out.
+func (stg Stage) Cell(i, j int) []Tile {
+	res := make([]Tile, 0, len(stg.Layers))
+
+	for layer := range stg.Layers {
+		if tile, ok := stg.Tiles[stg.ValueAt(layer, i, j)&tileGIDMask]; ok {
+			res = append(res, tile)
+		}
+	}
+
+	for _, spawn := range stg.Spawns {
+		if spawn.I == i && spawn.J == j {
+			res = append(res, spawn.Tile)
+		}
+	}
+
+	return res
+}
+
+// Tile returns what the cell at (i, j) means, combining its tiles. The top most theme and picture win.
+func (stg Stage) Tile(i, j int) (res Tile) {
+	for _, tile := range stg.Cell(i, j) {
+		res.Wall = res.Wall || tile.Wall
+		res.Floor = res.Floor || tile.Floor
+		res.Goal = res.Goal || tile.Goal
+		res.Box = res.Box || tile.Box
+		res.Player = res.Player || tile.Player
+
+		if tile.Theme != 0 {
+			res.Theme = tile.Theme
+		}
+
+		if tile.Sprite != "" || tile.Image != nil {
+			res.Sprite, res.Image = tile.Sprite, tile.Image
+		}
+	}
+
+	return res
+}
+
+// ThemeTiles returns the tiles drawn under a player or box of a theme, the floor and flag of the
+// lowest gids with it. A tile is zero when the tile sets of the stage have none.
+func (stg Stage) ThemeTiles(theme int) (floor, flag Tile) {
+	gids := make([]int, 0, len(stg.Tiles))
+	for gid := range stg.Tiles {
+		gids = append(gids, gid)
+	}
+
+	sort.Ints(gids)
+
+	foundFloor, foundFlag := false, false
+
+	for _, gid := range gids {
+		tile := stg.Tiles[gid]
+		if tile.Theme != theme || tile.Wall || tile.Box || tile.Player {
+			continue
+		}
+
+		switch {
+		case tile.Goal && !foundFlag:
+			flag, foundFlag = tile, true
+		case tile.Floor && !tile.Goal && !foundFloor:
+			floor, foundFloor = tile, true
+		}
+	}
+
+	return floor, flag
+}
+
+// hasTheme reports whether the tile sets of the stage have a floor of theme.
+func (stg Stage) hasTheme(theme int) bool {
+	floor, _ := stg.ThemeTiles(theme)
+
+	return floor.Floor
+}
+
+// Extent returns the width and height, from the top left corner, of the part of the stage with walls,
+// floors, flags, boxes or the player. Background past it may be left off screen.
+func (stg Stage) Extent() (width, height int) {
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			tile := stg.Tile(i, j)
+			if !tile.Wall && !tile.Floor && !tile.Goal && !tile.Box && !tile.Player {
+				continue
+			}
+
+			if i >= width {
+				width = i + 1
+			}
+
+			if j >= height {
+				height = j + 1
+			}
+		}
+	}
+
+	return width, height
+}
+
+// floorTheme returns the theme of the first themed floor of the stage, or zero.
+func (stg Stage) floorTheme() int {
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			for _, tile := range stg.Cell(i, j) {
+				if tile.Floor && tile.Theme != 0 {
+					return tile.Theme
+				}
+			}
+		}
+	}
+
+	return 0
+}
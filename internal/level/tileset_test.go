@@ -0,0 +1,244 @@
+package level
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+)
+
+func pngFile(t *testing.T, width, height int) *fstest.MapFile {
+	t.Helper()
+
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return &fstest.MapFile{Data: b.Bytes()}
+}
+
+func tsxImage(source string) *TSXImage {
+	return &TSXImage{Source: source, Width: 0, Height: 0}
+}
+
+func TestCutTiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sets/wide.png":   pngFile(t, 48, 24),
+		"sets/spaced.png": pngFile(t, 54, 30),
+		"sets/narrow.png": pngFile(t, 16, 24),
+	}
+
+	tests := []struct {
+		name  string
+		tsx   TSX
+		rects map[int]image.Rectangle
+		err   error
+	}{
+		{
+			name:  "size from image",
+			tsx:   TSX{Name: "", TileWidth: 24, TileHeight: 24, TileCount: 0, Columns: 0, Spacing: 0, Margin: 0, Image: tsxImage("wide.png"), Tiles: nil},
+			rects: map[int]image.Rectangle{0: image.Rect(0, 0, 24, 24), 1: image.Rect(24, 0, 48, 24)},
+			err:   nil,
+		},
+		{
+			name:  "margin and spacing",
+			tsx:   TSX{Name: "", TileWidth: 24, TileHeight: 24, TileCount: 2, Columns: 2, Spacing: 2, Margin: 2, Image: tsxImage("spaced.png"), Tiles: nil},
+			rects: map[int]image.Rectangle{0: image.Rect(2, 2, 26, 26), 1: image.Rect(28, 2, 52, 26)},
+			err:   nil,
+		},
+		{
+			name:  "more tiles than the image holds",
+			tsx:   TSX{Name: "", TileWidth: 24, TileHeight: 24, TileCount: 3, Columns: 2, Spacing: 0, Margin: 0, Image: tsxImage("wide.png"), Tiles: nil},
+			rects: nil,
+			err:   ErrTileOutsideImage,
+		},
+		{
+			name:  "image narrower than a tile",
+			tsx:   TSX{Name: "", TileWidth: 24, TileHeight: 24, TileCount: 1, Columns: 0, Spacing: 0, Margin: 0, Image: tsxImage("narrow.png"), Tiles: nil},
+			rects: nil,
+			err:   ErrNoColumns,
+		},
+		{
+			name:  "no tile size",
+			tsx:   TSX{Name: "", TileWidth: 0, TileHeight: 0, TileCount: 1, Columns: 1, Spacing: 0, Margin: 0, Image: tsxImage("wide.png"), Tiles: nil},
+			rects: nil,
+			err:   ErrNoTileSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, err := cutTiles(fsys, "sets", tt.tsx)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("cutTiles() error = %v, want %v", err, tt.err)
+			}
+
+			if len(images) != len(tt.rects) {
+				t.Fatalf("cutTiles() = %d tiles, want %d", len(images), len(tt.rects))
+			}
+
+			for id, rect := range tt.rects {
+				if images[id].Rect != rect || images[id].Source != "sets/"+tt.tsx.Image.Source {
+					t.Errorf("tile %d = %v of %s, want %v", id, images[id].Rect, images[id].Source, rect)
+				}
+			}
+		})
+	}
+}
+
+func TestReadTileset(t *testing.T) {
+	fsys := fstest.MapFS{
+		"set.png":  pngFile(t, 72, 24),
+		"hero.png": pngFile(t, 24, 32),
+	}
+
+	prop := func(name, value string) TMXProperty {
+		return TMXProperty{Name: name, Type: "", Value: value, Text: ""}
+	}
+
+	tile := func(id int, props ...TMXProperty) TSXTile {
+		return TSXTile{ID: id, Image: nil, Properties: props}
+	}
+
+	sheet := func(tiles ...TSXTile) TSX {
+		return TSX{Name: "set", TileWidth: 24, TileHeight: 24, TileCount: 3, Columns: 3, Spacing: 0, Margin: 0, Image: tsxImage("set.png"), Tiles: tiles}
+	}
+
+	tests := []struct {
+		name  string
+		tsx   TSX
+		check func(t *testing.T, tiles map[int]Tile)
+		err   error
+	}{
+		{
+			name: "decoration",
+			tsx:  sheet(),
+			check: func(t *testing.T, tiles map[int]Tile) {
+				if len(tiles) != 3 || tiles[2].Image == nil || tiles[2].Wall || tiles[2].Floor {
+					t.Errorf("tiles = %+v, want 3 plain tiles", tiles)
+				}
+			},
+			err: nil,
+		},
+		{
+			name: "properties",
+			tsx:  sheet(tile(0, prop("wall", "true")), tile(1, prop("floor", "true"), prop("theme", "2")), tile(2, prop("box", "true"), prop("sprite", "box3"))),
+			check: func(t *testing.T, tiles map[int]Tile) {
+				if !tiles[0].Wall || !tiles[1].Floor || tiles[1].Theme != 2 || !tiles[2].Box || tiles[2].Sprite != "box3" {
+					t.Errorf("tiles = %+v", tiles)
+				}
+			},
+			err: nil,
+		},
+		{
+			name: "box pictures",
+			tsx:  sheet(tile(0, prop("box", "true"), prop("onGoal", "1"), prop("offGoal", "2"))),
+			check: func(t *testing.T, tiles map[int]Tile) {
+				if tiles[0].OnGoal != tiles[1].Image || tiles[0].OffGoal != tiles[2].Image {
+					t.Errorf("box pictures = %v, %v", tiles[0].OnGoal, tiles[0].OffGoal)
+				}
+			},
+			err: nil,
+		},
+		{
+			name: "tile image",
+			tsx: TSX{Name: "heroes", TileWidth: 24, TileHeight: 32, TileCount: 1, Columns: 0, Spacing: 0, Margin: 0, Image: nil, Tiles: []TSXTile{
+				{ID: 4, Image: tsxImage("hero.png"), Properties: []TMXProperty{prop("player", "true")}},
+			}},
+			check: func(t *testing.T, tiles map[int]Tile) {
+				if !tiles[4].Player || tiles[4].Image == nil || tiles[4].Image.Rect != image.Rect(0, 0, 24, 32) {
+					t.Errorf("tiles = %+v", tiles)
+				}
+			},
+			err: nil,
+		},
+		{
+			name:  "bad property",
+			tsx:   sheet(tile(0, prop("wall", "maybe"))),
+			check: nil,
+			err:   strconv.ErrSyntax,
+		},
+		{
+			name:  "box picture of unknown tile",
+			tsx:   sheet(tile(0, prop("onGoal", "7"))),
+			check: nil,
+			err:   ErrUnknownTile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles, err := readTileset(fsys, ".", tt.tsx)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("readTileset() error = %v, want %v", err, tt.err)
+			}
+
+			if tt.check != nil {
+				tt.check(t, tiles)
+			}
+		})
+	}
+}
+
+func TestLoadTilesFirstGID(t *testing.T) {
+	fsys := fstest.MapFS{
+		"maps/set.png": pngFile(t, 48, 24),
+		"tiles.tsx": &fstest.MapFile{Data: []byte(`<tileset name="shared" tilewidth="24" tileheight="24" tilecount="2" columns="2">
+ <image source="maps/set.png" width="48" height="24"/>
+ <tile id="1"><properties><property name="wall" type="bool" value="true"/></properties></tile>
+</tileset>`)},
+	}
+
+	inline := TSX{Name: "inline", TileWidth: 24, TileHeight: 24, TileCount: 2, Columns: 2, Spacing: 0, Margin: 0, Image: tsxImage("set.png"), Tiles: []TSXTile{
+		{ID: 0, Image: nil, Properties: []TMXProperty{{Name: "goal", Type: "bool", Value: "true", Text: ""}}},
+	}}
+
+	tmx := TMX{
+		XMLName:    xml.Name{Space: "", Local: "map"},
+		Width:      0,
+		Height:     0,
+		TileWidth:  24,
+		TileHeight: 24,
+		Tilesets: []TMXTileset{
+			{FirstGID: 1, Source: "../tiles.tsx", TSX: TSX{Name: "", TileWidth: 0, TileHeight: 0, TileCount: 0, Columns: 0, Spacing: 0, Margin: 0, Image: nil, Tiles: nil}},
+			{FirstGID: 10, Source: "", TSX: inline},
+		},
+		Layers:       nil,
+		ObjectGroups: nil,
+		Properties:   nil,
+	}
+
+	tiles, err := loadTiles(fsys, "maps/stage.tmx", tmx, make(tilesetCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		gid  int
+		wall bool
+		goal bool
+	}{
+		{gid: 1, wall: false, goal: false},
+		{gid: 2, wall: true, goal: false},
+		{gid: 10, wall: false, goal: true},
+		{gid: 11, wall: false, goal: false},
+	}
+
+	for _, tt := range tests {
+		tile, ok := tiles[tt.gid]
+		if !ok || tile.Wall != tt.wall || tile.Goal != tt.goal {
+			t.Errorf("gid %d = %+v, %v, want wall %v goal %v", tt.gid, tile, ok, tt.wall, tt.goal)
+		}
+	}
+
+	if len(tiles) != len(tests) {
+		t.Errorf("loadTiles() = %d tiles, want %d", len(tiles), len(tests))
+	}
+}
@@ -0,0 +1,206 @@
+package level
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSizeMismatch    = errors.New("data size doesn't match map size")
+	ErrRaggedRow       = errors.New("row length doesn't match map width")
+	ErrUnknownTile     = errors.New("unknown tile")
+	ErrUnknownTheme    = errors.New("unknown theme")
+	ErrNoLayers        = errors.New("no tile layers")
+	ErrOutsideMap      = errors.New("object outside the map")
+	ErrStacked         = errors.New("more than one of wall, player and box in a cell")
+	ErrNoPlayer        = errors.New("no player")
+	ErrManyPlayers     = errors.New("more than one player")
+	ErrBoxGoalMismatch = errors.New("more boxes than flags")
+	ErrNotEnclosed     = errors.New("player can walk off the map")
+	ErrUnreachableFlag = errors.New("flag can't be reached")
+)
+
+const noPosition = -1
+
+// StageError is a problem found in a stage. I and J are -1 when it isn't about a single cell.
+type StageError struct {
+	Stage string
+	I, J  int
+	Err   error
+}
+
+func (err *StageError) Error() string {
+	if err.I == noPosition && err.J == noPosition {
+		return fmt.Sprintf("stage '%s': %v", err.Stage, err.Err)
+	}
+
+	return fmt.Sprintf("stage '%s' at (%d, %d): %v", err.Stage, err.I, err.J, err.Err)
+}
+
+func (err *StageError) Unwrap() error {
+	return err.Err
+}
+
+func (stg Stage) problem(i, j int, err error) *StageError {
+	return &StageError{Stage: stg.Name, I: i, J: j, Err: err}
+}
+
+// Problems checks the stage is well formed and playable.
+// Structural problems stop the check early since the rest would be meaningless.
+func (stg Stage) Problems() []*StageError {
+	if len(stg.Layers) == 0 {
+		return []*StageError{stg.problem(noPosition, noPosition, ErrNoLayers)}
+	}
+
+	for _, data := range stg.Layers {
+		if len(data) != stg.TMX.Height {
+			return []*StageError{stg.problem(noPosition, noPosition, errors.Wrapf(ErrSizeMismatch, "%d rows for height %d", len(data), stg.TMX.Height))}
+		}
+
+		for j := range data {
+			if len(data[j]) != stg.TMX.Width {
+				return []*StageError{stg.problem(noPosition, j, errors.Wrapf(ErrRaggedRow, "%d columns for width %d", len(data[j]), stg.TMX.Width))}
+			}
+		}
+	}
+
+	res := make([]*StageError, 0)
+
+	for _, spawn := range stg.Spawns {
+		if spawn.I < 0 || spawn.J < 0 || spawn.I >= stg.TMX.Width || spawn.J >= stg.TMX.Height {
+			res = append(res, stg.problem(spawn.I, spawn.J, ErrOutsideMap))
+		}
+	}
+
+	if stg.Theme != 0 && !stg.hasTheme(stg.Theme) {
+		res = append(res, stg.problem(noPosition, noPosition, errors.Wrapf(ErrUnknownTheme, "%d", stg.Theme)))
+	}
+
+	players, boxes, goals := 0, 0, 0
+
+	for j := 0; j < stg.TMX.Height; j++ {
+		for i := 0; i < stg.TMX.Width; i++ {
+			for layer := range stg.Layers {
+				if v := stg.ValueAt(layer, i, j); v != 0 {
+					if _, ok := stg.Tiles[v&tileGIDMask]; !ok {
+						res = append(res, stg.problem(i, j, errors.Wrapf(ErrUnknownTile, "%d", v)))
+					}
+				}
+			}
+
+			if tile := stg.Tile(i, j); tile.Theme != 0 && !stg.hasTheme(tile.Theme) {
+				res = append(res, stg.problem(i, j, errors.Wrapf(ErrUnknownTheme, "%d", tile.Theme)))
+			}
+
+			for _, tile := range stg.Cell(i, j) {
+				if tile.Player {
+					players++
+
+					if players > 1 {
+						res = append(res, stg.problem(i, j, ErrManyPlayers))
+					}
+				}
+			}
+
+			if tile := stg.Tile(i, j); countTrue(tile.Wall, tile.Player, tile.Box) > 1 {
+				res = append(res, stg.problem(i, j, ErrStacked))
+			}
+
+			if stg.IsBox(i, j) {
+				boxes++
+			}
+
+			if stg.IsFlag(i, j) {
+				goals++
+			}
+		}
+	}
+
+	if players == 0 {
+		res = append(res, stg.problem(noPosition, noPosition, ErrNoPlayer))
+	}
+
+	// a spare flag is harmless since a stage is solved once every box is on a flag
+	if boxes > goals {
+		res = append(res, stg.problem(noPosition, noPosition, errors.Wrapf(ErrBoxGoalMismatch, "%d boxes, %d flags", boxes, goals)))
+	}
+
+	if players == 1 {
+		res = append(res, stg.reachProblems()...)
+	}
+
+	return res
+}
+
+// reachProblems checks walls keep the player, and so boxes, away from the map edges,
+// and that every flag is inside the area the player can walk.
+func (stg Stage) reachProblems() []*StageError {
+	state := stg.NewState()
+
+	reach := map[sokoban.Position]bool{state.Player(): true}
+	queue := []sokoban.Position{state.Player()}
+
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+
+		if pos.I == 0 || pos.J == 0 || pos.I == state.Width()-1 || pos.J == state.Height()-1 {
+			return []*StageError{stg.problem(pos.I, pos.J, ErrNotEnclosed)}
+		}
+
+		for _, dir := range sokoban.Directions() {
+			next := pos.Next(dir)
+			if state.IsWall(next) || reach[next] {
+				continue
+			}
+
+			reach[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	res := make([]*StageError, 0)
+
+	for _, goal := range state.Goals() {
+		if !reach[goal] {
+			res = append(res, stg.problem(goal.I, goal.J, ErrUnreachableFlag))
+		}
+	}
+
+	return res
+}
+
+func countTrue(values ...bool) (res int) {
+	for _, v := range values {
+		if v {
+			res++
+		}
+	}
+
+	return res
+}
+
+// StageErrors is every problem found in a stage.
+type StageErrors []*StageError
+
+func (errs StageErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i := range errs {
+		msgs[i] = errs[i].Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Validate returns problems of the stage as StageErrors, or nil.
+func (stg Stage) Validate() error {
+	problems := stg.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return StageErrors(problems)
+}
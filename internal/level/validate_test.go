@@ -0,0 +1,149 @@
+package level
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func xsbStage(t *testing.T, rows ...string) Stage {
+	t.Helper()
+
+	stages, err := ParseXSB("test", strings.NewReader(strings.Join(rows, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stages[0]
+}
+
+func TestProblems(t *testing.T) {
+	valid := []string{"######", "#@$ .#", "######"}
+
+	tests := []struct {
+		name   string
+		rows   []string
+		change func(stg *Stage)
+		want   []error
+	}{
+		{
+			name:   "valid",
+			rows:   valid,
+			change: func(stg *Stage) {},
+			want:   []error{},
+		},
+		{
+			name:   "no layers",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Layers = nil },
+			want:   []error{ErrNoLayers},
+		},
+		{
+			name:   "missing row",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Layers[0] = stg.Layers[0][1:] },
+			want:   []error{ErrSizeMismatch},
+		},
+		{
+			name:   "short row",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Layers[0][2] = stg.Layers[0][2][1:] },
+			want:   []error{ErrRaggedRow},
+		},
+		{
+			name:   "unknown tile",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Layers[0][0][0] = 999 },
+			want:   []error{ErrUnknownTile},
+		},
+		{
+			name:   "unknown theme",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Theme = 9 },
+			want:   []error{ErrUnknownTheme},
+		},
+		{
+			name: "spawn outside the map",
+			rows: valid,
+			change: func(stg *Stage) {
+				stg.Spawns = []Spawn{{I: -1, J: 0, Tile: Tile{Wall: false, Floor: false, Goal: true, Box: false, Player: false, Theme: 0, Sprite: "", Image: nil, OnGoal: nil, OffGoal: nil}}}
+			},
+			want: []error{ErrOutsideMap},
+		},
+		{
+			name: "box on a wall",
+			rows: valid,
+			change: func(stg *Stage) {
+				stg.Spawns = []Spawn{{I: 4, J: 3, Tile: stg.Tiles[ItemBox1]}}
+			},
+			want: []error{ErrStacked, ErrBoxGoalMismatch},
+		},
+		{
+			name:   "no player",
+			rows:   valid,
+			change: func(stg *Stage) { stg.Layers[0][4][5] = ItemTile1 },
+			want:   []error{ErrNoPlayer},
+		},
+		{
+			name:   "two players",
+			rows:   []string{"######", "#@$@.#", "######"},
+			change: func(stg *Stage) {},
+			want:   []error{ErrManyPlayers},
+		},
+		{
+			name:   "more boxes than flags",
+			rows:   []string{"######", "#@$$.#", "######"},
+			change: func(stg *Stage) {},
+			want:   []error{ErrBoxGoalMismatch},
+		},
+		{
+			name:   "open side",
+			rows:   []string{"######", "#@$ . ", "######"},
+			change: func(stg *Stage) {},
+			want:   []error{ErrNotEnclosed},
+		},
+		{
+			name:   "walled off flag",
+			rows:   []string{"#######", "#@$.#.#", "#######"},
+			change: func(stg *Stage) {},
+			want:   []error{ErrUnreachableFlag},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stg := xsbStage(t, tt.rows...)
+			tt.change(&stg)
+
+			problems := stg.Problems()
+			if len(problems) != len(tt.want) {
+				t.Fatalf("Problems() = %v, want %v", problems, tt.want)
+			}
+
+			for i := range problems {
+				if !errors.Is(problems[i], tt.want[i]) {
+					t.Errorf("problem %d = %v, want %v", i, problems[i], tt.want[i])
+				}
+			}
+
+			if err := stg.Validate(); (err == nil) != (len(tt.want) == 0) {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
+
+func TestStageErrorPosition(t *testing.T) {
+	stg := xsbStage(t, "#######", "#@$.#.#", "#######")
+
+	problems := stg.Problems()
+	if len(problems) != 1 {
+		t.Fatalf("Problems() = %v", problems)
+	}
+
+	// 7x3 level centered on 14x10
+	if got := problems[0]; got.Stage != "test" || got.I != 3+5 || got.J != 3+1 {
+		t.Errorf("problem at %s (%d, %d), want test (8, 4)", got.Stage, got.I, got.J)
+	}
+}
@@ -0,0 +1,248 @@
+package level
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// XSB is the plain text level notation used by most Sokoban tools.
+const (
+	xsbWall         = '#'
+	xsbPlayer       = '@'
+	xsbPlayerOnGoal = '+'
+	xsbBox          = '$'
+	xsbBoxOnGoal    = '*'
+	xsbGoal         = '.'
+	xsbFloor        = ' '
+	xsbFloorAlt1    = '-'
+	xsbFloorAlt2    = '_'
+)
+
+// Imported levels are centered on a grid of at least the size of the shipped stages.
+const (
+	minWidth  = 14
+	minHeight = 10
+)
+
+var ErrXSBNoPlayer = errors.New("level has no player")
+
+func isXSBLine(line string) bool {
+	if !strings.ContainsRune(line, xsbWall) {
+		return false
+	}
+
+	for _, c := range line {
+		switch c {
+		case xsbWall, xsbPlayer, xsbPlayerOnGoal, xsbBox, xsbBoxOnGoal, xsbGoal, xsbFloor, xsbFloorAlt1, xsbFloorAlt2:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+type xsbLevel struct {
+	title string
+	lines []string
+}
+
+// ParseXSB reads one or more levels in XSB notation.
+// Levels are separated by any non level line, lines starting with ';' are comments,
+// and a "Title:" line after a level or a plain text line before it names the level.
+// Untitled levels are named after name and their position in the collection.
+func ParseXSB(name string, r io.Reader) ([]Stage, error) {
+	levels := make([]*xsbLevel, 0)
+
+	var (
+		current *xsbLevel
+		caption string
+	)
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if isXSBLine(line) {
+			if current == nil {
+				current = &xsbLevel{title: caption, lines: make([]string, 0)}
+				levels = append(levels, current)
+				caption = ""
+			}
+
+			current.lines = append(current.lines, line)
+
+			continue
+		}
+
+		current = nil
+		text := strings.TrimSpace(line)
+
+		switch {
+		case text == "", strings.HasPrefix(text, ";"):
+		case strings.HasPrefix(strings.ToLower(text), "title:"):
+			title := strings.TrimSpace(text[len("title:"):])
+			if len(levels) > 0 {
+				levels[len(levels)-1].title = title
+			} else {
+				caption = title
+			}
+		case strings.Contains(text, ":"):
+		default:
+			caption = text
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error on scan xsb")
+	}
+
+	res := make([]Stage, 0, len(levels))
+
+	for i := range levels {
+		title := levels[i].title
+
+		switch {
+		case title != "":
+		case len(levels) == 1:
+			title = name
+		default:
+			title = name + " " + strconv.Itoa(i+1)
+		}
+
+		stg, err := newXSBStage(title, levels[i].lines)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error on level %d", i+1)
+		}
+
+		res = append(res, stg)
+	}
+
+	return res, nil
+}
+
+// newXSBStage maps an XSB level on the default theme, centered on a grid of at least the default stage size.
+func newXSBStage(name string, lines []string) (Stage, error) {
+	levelWidth := 0
+	for i := range lines {
+		if len(lines[i]) > levelWidth {
+			levelWidth = len(lines[i])
+		}
+	}
+
+	width, height := levelWidth, len(lines)
+	if width < minWidth {
+		width = minWidth
+	}
+
+	if height < minHeight {
+		height = minHeight
+	}
+
+	offsetX, offsetY := (width-levelWidth)/2, (height-len(lines))/2
+
+	data := make([][]int, height)
+	for j := range data {
+		data[j] = make([]int, width)
+		for i := range data[j] {
+			data[j][i] = ItemBackground1
+		}
+	}
+
+	playerX, playerY := -1, -1
+
+	for j := range lines {
+		for i, c := range lines[j] {
+			x, y := i+offsetX, j+offsetY
+
+			switch c {
+			case xsbWall:
+				data[y][x] = ItemWall1
+			case xsbPlayer:
+				data[y][x] = ItemPlayer1
+				playerX, playerY = x, y
+			case xsbPlayerOnGoal:
+				data[y][x] = ItemPlayerFlagged1
+				playerX, playerY = x, y
+			case xsbBox:
+				data[y][x] = ItemBox1
+			case xsbBoxOnGoal:
+				data[y][x] = ItemBoxDone1
+			case xsbGoal:
+				data[y][x] = ItemTileFlagged1
+			}
+		}
+	}
+
+	if playerX < 0 {
+		return Stage{}, ErrXSBNoPlayer
+	}
+
+	fillXSBFloor(data, playerX, playerY)
+
+	return Stage{
+		Name:   name,
+		Pack:   "",
+		Layers: [][][]int{data},
+		Spawns: nil,
+		Tiles:  builtinTiles(),
+		TMX: TMX{
+			XMLName:      xml.Name{Space: "", Local: "map"},
+			Width:        width,
+			Height:       height,
+			TileWidth:    TileSize,
+			TileHeight:   TileSize,
+			Tilesets:     nil,
+			Layers:       nil,
+			ObjectGroups: nil,
+			Properties:   nil,
+		},
+		Title:      "",
+		Author:     "",
+		Difficulty: "",
+		Hint:       "",
+		Theme:      defaultTheme,
+		ParMoves:   0,
+		ParPushes:  0,
+	}, nil
+}
+
+// fillXSBFloor turns background reachable from the player into floor,
+// so spaces outside the walls stay background.
+func fillXSBFloor(data [][]int, i, j int) {
+	type cell struct{ i, j int }
+
+	visited := make(map[cell]bool)
+	queue := []cell{{i: i, j: j}}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		if c.j < 0 || c.j >= len(data) || c.i < 0 || c.i >= len(data[c.j]) || visited[c] {
+			continue
+		}
+
+		visited[c] = true
+
+		switch data[c.j][c.i] {
+		case ItemWall1:
+			continue
+		case ItemBackground1:
+			data[c.j][c.i] = ItemTile1
+		}
+
+		queue = append(queue, cell{i: c.i - 1, j: c.j}, cell{i: c.i + 1, j: c.j}, cell{i: c.i, j: c.j - 1}, cell{i: c.i, j: c.j + 1})
+	}
+}
+
+// XSB encodes the stage at its starting position in XSB notation.
+func (stg Stage) XSB() string {
+	return stg.NewState().XSB() + "Title: " + stg.DisplayName() + "\n"
+}
@@ -0,0 +1,161 @@
+package level
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseXSB(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		titles []string
+		boards []string
+		err    error
+	}{
+		{
+			name:   "single level",
+			in:     "#####\n#@$.#\n#####\n",
+			titles: []string{"pack"},
+			boards: []string{"#####\n#@$.#\n#####\n"},
+			err:    nil,
+		},
+		{
+			name:   "title line",
+			in:     "#####\n#@$.#\n#####\nTitle: First\n\n#####\n#+*-#\n#####\n",
+			titles: []string{"First", "pack 2"},
+			boards: []string{"#####\n#@$.#\n#####\n", "#####\n#+* #\n#####\n"},
+			err:    nil,
+		},
+		{
+			name:   "caption and comments",
+			in:     "; a comment\nOne\n#####\n#@$.#\n#####\nAuthor: someone\n\nTwo\n#####\n#.$@#\n#####\n",
+			titles: []string{"One", "Two"},
+			boards: []string{"#####\n#@$.#\n#####\n", "#####\n#.$@#\n#####\n"},
+			err:    nil,
+		},
+		{
+			name:   "windows line endings",
+			in:     "#####\r\n#@$.#\r\n#####\r\n",
+			titles: []string{"pack"},
+			boards: []string{"#####\n#@$.#\n#####\n"},
+			err:    nil,
+		},
+		{
+			name:   "no levels",
+			in:     "just text\n",
+			titles: []string{},
+			boards: []string{},
+			err:    nil,
+		},
+		{
+			name:   "no player",
+			in:     "#####\n# $.#\n#####\n",
+			titles: nil,
+			boards: nil,
+			err:    ErrXSBNoPlayer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := ParseXSB("pack", strings.NewReader(tt.in))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseXSB() error = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			titles := make([]string, len(stages))
+			boards := make([]string, len(stages))
+
+			for i := range stages {
+				titles[i] = stages[i].Name
+				boards[i] = stages[i].NewState().XSB()
+			}
+
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("names = %q, want %q", titles, tt.titles)
+			}
+
+			if !reflect.DeepEqual(boards, tt.boards) {
+				t.Errorf("boards = %q, want %q", boards, tt.boards)
+			}
+		})
+	}
+}
+
+func TestXSBStageLayout(t *testing.T) {
+	stages, err := ParseXSB("pack", strings.NewReader("  ####\n###  #\n#@$ .#\n######\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stg := stages[0]
+
+	if err := stg.Validate(); err != nil {
+		t.Fatalf("stage isn't valid: %v", err)
+	}
+
+	if stg.TMX.Width != minWidth || stg.TMX.Height != minHeight {
+		t.Errorf("size = %dx%d, want %dx%d", stg.TMX.Width, stg.TMX.Height, minWidth, minHeight)
+	}
+
+	// 6x4 level centered on 14x10
+	offsetX, offsetY := 4, 3
+
+	tests := []struct {
+		name string
+		i, j int
+		want int
+	}{
+		{name: "margin", i: -offsetX, j: -offsetY, want: ItemBackground1},
+		{name: "space outside the walls", i: 0, j: 0, want: ItemBackground1},
+		{name: "wall", i: 0, j: 1, want: ItemWall1},
+		{name: "player", i: 1, j: 2, want: ItemPlayer1},
+		{name: "box", i: 2, j: 2, want: ItemBox1},
+		{name: "floor", i: 3, j: 2, want: ItemTile1},
+		{name: "floor in a nook", i: 3, j: 1, want: ItemTile1},
+		{name: "goal", i: 4, j: 2, want: ItemTileFlagged1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stg.ValueAt(0, tt.i+offsetX, tt.j+offsetY); got != tt.want {
+				t.Errorf("ValueAt(%d, %d) = %d, want %d", tt.i, tt.j, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageXSB(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "untitled", title: "", want: "#####\n#@$.#\n#####\nTitle: 7\n"},
+		{name: "titled", title: "First Steps", want: "#####\n#@$.#\n#####\nTitle: First Steps\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := ParseXSB("7", strings.NewReader("#####\n#@$.#\n#####\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stg := stages[0]
+			stg.Title = tt.title
+
+			if got := stg.XSB(); got != tt.want {
+				t.Errorf("XSB() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
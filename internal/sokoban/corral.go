@@ -0,0 +1,90 @@
+package sokoban
+
+// corralPushes returns the pushes into a PI-corral, an area the player can't reach fenced in by boxes
+// that can only be pushed into it, each from where the player can walk to now. A solution has to push
+// into such a corral and doing it first costs no pushes, so other pushes can wait. It returns nil when
+// there's no corral like that. Boxes are marked and cells reachable by the player visited.
+func (s *solver) corralPushes() []push {
+	for cell := range s.corral {
+		s.corral[cell] = unreachable
+	}
+
+	var res []push
+
+	for cell := range s.floor {
+		if !s.floor[cell] || s.boxAt[cell] || s.visited[cell] != unreachable || s.corral[cell] != unreachable {
+			continue
+		}
+
+		pushes, ok := s.fence(s.fillCorral(cell), cell)
+		if ok && (res == nil || len(pushes) < len(res)) {
+			res = pushes
+		}
+	}
+
+	return res
+}
+
+// fillCorral labels the free cells the player could walk to from a cell with it and returns them.
+func (s *solver) fillCorral(from int) []int {
+	s.corral[from] = from
+	area := []int{from}
+
+	for k := 0; k < len(area); k++ {
+		for _, next := range s.neighbors[area[k]] {
+			if next == unreachable || s.boxAt[next] || s.corral[next] != unreachable {
+				continue
+			}
+
+			s.corral[next] = from
+			area = append(area, next)
+		}
+	}
+
+	return area
+}
+
+// fence returns the pushes into the corral of area labeled with label, and whether it's a PI-corral
+// the player has to enter, holding an empty goal or fenced in by a box off goal. Boxes of the fence
+// can't move before a push into the corral, other boxes next to them might.
+func (s *solver) fence(area []int, label int) ([]push, bool) {
+	needed := false
+	boxes := make([]int, 0)
+
+	s.mark++
+
+	for _, cell := range area {
+		needed = needed || s.st.goals[cell]
+
+		for _, box := range s.neighbors[cell] {
+			if box != unreachable && s.boxAt[box] && s.marks[box] != s.mark {
+				s.marks[box] = s.mark
+				boxes = append(boxes, box)
+				needed = needed || !s.st.goals[box]
+			}
+		}
+	}
+
+	res := make([]push, 0)
+
+	for _, box := range boxes {
+		for _, dir := range Directions() {
+			from, to := s.step(box, dir.Opposite()), s.step(box, dir)
+
+			switch {
+			case from == unreachable || to == unreachable || s.distance[to] == unreachable:
+			case s.boxAt[from] && s.marks[from] != s.mark, s.boxAt[to] && s.marks[to] != s.mark:
+				return nil, false
+			case s.boxAt[from] || s.boxAt[to] || s.corral[from] == label:
+				// blocked by the fence, or only after the player gets in
+			case s.corral[to] == label && s.visited[from] != unreachable:
+				res = append(res, push{box: box, dir: dir})
+			default:
+				// pushed elsewhere, or into the corral from where the player can't walk to now
+				return nil, false
+			}
+		}
+	}
+
+	return res, needed && len(res) > 0
+}
@@ -0,0 +1,83 @@
+package sokoban
+
+// DeadSquares marks cells from which a box can never reach a goal, even without other boxes around.
+// It's computed once and shared by clones.
+func (st *State) DeadSquares() []bool {
+	if st.dead == nil {
+		distances := st.pushDistances()
+		st.dead = make([]bool, len(distances))
+
+		for i := range distances {
+			st.dead[i] = distances[i] == unreachable && !st.walls[i]
+		}
+	}
+
+	return st.dead
+}
+
+func (st *State) IsDeadSquare(pos Position) bool {
+	return st.Inside(pos) && st.DeadSquares()[st.index(pos)]
+}
+
+// freezeCheck tells whether a box can never move again.
+// Boxes already visited are treated as walls to break cycles.
+type freezeCheck struct {
+	st      *State
+	isBox   func(pos Position) bool
+	visited map[Position]bool
+}
+
+func (fc *freezeCheck) frozen(pos Position) bool {
+	fc.visited[pos] = true
+
+	return fc.blocked(pos, Left, Right) && fc.blocked(pos, Up, Down)
+}
+
+func (fc *freezeCheck) blocked(pos Position, a, b Direction) bool {
+	na, nb := pos.Next(a), pos.Next(b)
+
+	switch {
+	case fc.st.IsWall(na) || fc.st.IsWall(nb) || fc.visited[na] || fc.visited[nb]:
+		return true
+	case fc.st.IsDeadSquare(na) && fc.st.IsDeadSquare(nb):
+		return true
+	case fc.isBox(na) && fc.frozen(na):
+		return true
+	case fc.isBox(nb) && fc.frozen(nb):
+		return true
+	default:
+		return false
+	}
+}
+
+func (st *State) isFrozen(pos Position, isBox func(pos Position) bool) bool {
+	fc := freezeCheck{
+		st:      st,
+		isBox:   isBox,
+		visited: make(map[Position]bool),
+	}
+
+	return fc.frozen(pos)
+}
+
+// Deadlocked returns indices of boxes off goal that can't reach one anymore,
+// either standing on a dead square or frozen against walls and other boxes.
+func (st *State) Deadlocked() []int {
+	res := make([]int, 0)
+
+	isBox := func(pos Position) bool {
+		return st.BoxAt(pos) != NoBox
+	}
+
+	for i := range st.boxes {
+		if st.IsGoal(st.boxes[i]) {
+			continue
+		}
+
+		if st.IsDeadSquare(st.boxes[i]) || st.isFrozen(st.boxes[i], isBox) {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,60 @@
+package sokoban
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeadlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		want []int
+	}{
+		{
+			name: "free",
+			rows: []string{"######", "#    #", "# $@.#", "#    #", "######"},
+			want: []int{},
+		},
+		{
+			name: "corner",
+			rows: []string{"#####", "#$  #", "# @.#", "#####"},
+			want: []int{0},
+		},
+		{
+			name: "corner goal",
+			rows: []string{"#####", "#*  #", "# @ #", "#####"},
+			want: []int{},
+		},
+		{
+			name: "wall without goal",
+			rows: []string{"#######", "#  $  #", "#  @  #", "#    .#", "#######"},
+			want: []int{0},
+		},
+		{
+			name: "wall with goal",
+			rows: []string{"#######", "#  $ .#", "#  @  #", "#######"},
+			want: []int{},
+		},
+		{
+			name: "frozen against a box",
+			rows: []string{"######", "#$$. #", "#  @.#", "######"},
+			want: []int{0, 1},
+		},
+		{
+			name: "square of boxes",
+			rows: []string{"#######", "#     #", "# $*  #", "# $$  #", "#  @..#", "#  ...#", "#######"},
+			want: []int{0, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, tt.rows...)
+
+			if got := st.Deadlocked(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Deadlocked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
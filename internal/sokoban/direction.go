@@ -0,0 +1,55 @@
+package sokoban
+
+type Direction int
+
+const (
+	Left Direction = iota
+	Right
+	Up
+	Down
+)
+
+// Directions lists every direction in the order of their values.
+func Directions() []Direction {
+	return []Direction{Left, Right, Up, Down}
+}
+
+func (dir Direction) Delta() (di, dj int) {
+	switch dir {
+	case Left:
+		return -1, 0
+	case Right:
+		return 1, 0
+	case Up:
+		return 0, -1
+	case Down:
+		return 0, 1
+	default:
+		return 0, 0
+	}
+}
+
+func (dir Direction) Opposite() Direction {
+	switch dir {
+	case Left:
+		return Right
+	case Right:
+		return Left
+	case Up:
+		return Down
+	case Down:
+		return Up
+	default:
+		return dir
+	}
+}
+
+type Position struct {
+	I, J int
+}
+
+func (pos Position) Next(dir Direction) Position {
+	di, dj := dir.Delta()
+
+	return Position{I: pos.I + di, J: pos.J + dj}
+}
@@ -0,0 +1,240 @@
+package sokoban
+
+// goalRoom is an area holding every goal that boxes can only enter through one cell.
+// Boxes entering it are pushed straight to the goal its fill order gives them and left there.
+type goalRoom struct {
+	entrance int
+	cells    []bool
+	fill     []int
+}
+
+// push is a push of the box on a cell towards a direction.
+type push struct {
+	box int
+	dir Direction
+}
+
+// findGoalRoom returns the smallest area holding every goal behind a single cell the player can walk to,
+// with an order it can be filled in, or nil when there's none.
+func (st *State) findGoalRoom(goals []int) *goalRoom {
+	if len(goals) == 0 {
+		return nil
+	}
+
+	floor := st.walkable(st.index(st.player), unreachable)
+
+	var res *goalRoom
+
+	size := len(st.walls)
+
+	for entrance := range floor {
+		if !floor[entrance] || st.goals[entrance] {
+			continue
+		}
+
+		cells := st.walkable(goals[0], entrance)
+
+		room, outside := 0, 0
+
+		for cell := range floor {
+			switch {
+			case cells[cell]:
+				room++
+			case floor[cell] && cell != entrance:
+				outside++
+			}
+		}
+
+		if outside == 0 || room >= size || !containsAll(cells, goals) {
+			continue
+		}
+
+		res, size = &goalRoom{entrance: entrance, cells: cells, fill: nil}, room
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	res.fill = res.fillOrder(st, goals)
+	if res.fill == nil {
+		return nil
+	}
+
+	return res
+}
+
+// walkable marks cells the player can walk to from a cell without passing another, ignoring boxes.
+func (st *State) walkable(from, blocked int) []bool {
+	res := make([]bool, len(st.walls))
+	res[from] = true
+	queue := []int{from}
+
+	for len(queue) > 0 {
+		pos := st.position(queue[0])
+		queue = queue[1:]
+
+		for _, dir := range Directions() {
+			next := pos.Next(dir)
+			if st.IsWall(next) || st.index(next) == blocked || res[st.index(next)] {
+				continue
+			}
+
+			res[st.index(next)] = true
+			queue = append(queue, st.index(next))
+		}
+	}
+
+	return res
+}
+
+func containsAll(cells []bool, list []int) bool {
+	for _, cell := range list {
+		if !cells[cell] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fillOrder finds the goals from the last filled to the first by taking boxes out of a full room,
+// each time the one nearest the entrance that can be pulled out. It returns nil if boxes get stuck.
+func (room *goalRoom) fillOrder(st *State, goals []int) []int {
+	filled := make([]bool, len(st.walls))
+	for _, goal := range goals {
+		filled[goal] = true
+	}
+
+	res := make([]int, len(goals))
+
+	for k := len(goals) - 1; k >= 0; k-- {
+		next, nearest := unreachable, unreachable
+
+		for _, goal := range goals {
+			if !filled[goal] {
+				continue
+			}
+
+			d := st.pullDistances([]int{goal})[room.entrance]
+			if (nearest == unreachable || d < nearest) && room.canPullOut(st, goal, filled) {
+				next, nearest = goal, d
+			}
+		}
+
+		if next == unreachable {
+			return nil
+		}
+
+		filled[next] = false
+		res[k] = next
+	}
+
+	return res
+}
+
+// canPullOut tells whether the box on a goal can be pulled to the entrance around the filled goals,
+// leaving the player outside, so it can be pushed in the other way.
+func (room *goalRoom) canPullOut(st *State, goal int, filled []bool) bool {
+	size := len(st.walls)
+
+	free := func(cell int, player bool) bool {
+		if cell == unreachable || (filled[cell] && cell != goal) {
+			return false
+		}
+
+		if room.cells[cell] || cell == room.entrance {
+			return true
+		}
+
+		// the player may step out next to the entrance
+		return player && st.adjacent(cell, room.entrance)
+	}
+
+	visited := make(map[int]bool)
+	queue := make([]int, 0)
+
+	for _, dir := range Directions() {
+		if player := st.step(goal, dir); free(player, true) {
+			visited[goal*size+player] = true
+			queue = append(queue, goal*size+player)
+		}
+	}
+
+	for len(queue) > 0 {
+		box, player := queue[0]/size, queue[0]%size
+		queue = queue[1:]
+
+		if box == room.entrance && !room.cells[player] {
+			return true
+		}
+
+		for _, dir := range Directions() {
+			next := st.step(player, dir)
+			if next == box || !free(next, true) {
+				continue
+			}
+
+			state := box*size + next
+
+			// pulling moves the box into the cell the player leaves
+			if st.step(box, dir) == player && free(player, false) {
+				state = player*size + next
+			}
+
+			if !visited[state] {
+				visited[state] = true
+				queue = append(queue, state)
+			}
+
+			if walk := box*size + next; !visited[walk] {
+				visited[walk] = true
+				queue = append(queue, walk)
+			}
+		}
+	}
+
+	return false
+}
+
+func (st *State) step(cell int, dir Direction) int {
+	pos := st.position(cell).Next(dir)
+	if st.IsWall(pos) {
+		return unreachable
+	}
+
+	return st.index(pos)
+}
+
+func (st *State) adjacent(a, b int) bool {
+	for _, dir := range Directions() {
+		if st.step(a, dir) == b {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filled returns how many goals of the fill order hold boxes, or -1 when boxes in the room are
+// anywhere else, with boxAt marking boxes.
+func (room *goalRoom) filled(boxAt []bool) int {
+	res := 0
+	for res < len(room.fill) && boxAt[room.fill[res]] {
+		res++
+	}
+
+	inside := 0
+
+	for cell := range room.cells {
+		if room.cells[cell] && boxAt[cell] {
+			inside++
+		}
+	}
+
+	if inside != res {
+		return unreachable
+	}
+
+	return res
+}
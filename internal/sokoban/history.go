@@ -0,0 +1,140 @@
+package sokoban
+
+// snapshot is everything a restart throws away.
+type snapshot struct {
+	boxes   []Position
+	player  Position
+	history []Step
+	future  []Step
+}
+
+// apply performs step, which must be legal, and pushes it on history.
+func (st *State) apply(step Step) {
+	st.player = st.player.Next(step.Direction)
+
+	if step.Pushed() {
+		st.moveBox(step.Box, st.boxes[step.Box].Next(step.Direction))
+	}
+
+	st.history = append(st.history, step)
+}
+
+// revert takes back the last step of history and returns it.
+func (st *State) revert() Step {
+	step := st.history[len(st.history)-1]
+	back := step.Direction.Opposite()
+
+	if step.Pushed() {
+		st.moveBox(step.Box, st.boxes[step.Box].Next(back))
+	}
+
+	st.player = st.player.Next(back)
+	st.history = st.history[:len(st.history)-1]
+
+	return step
+}
+
+// Undo reverts the last step, keeps it for Redo and returns it.
+func (st *State) Undo() (Step, bool) {
+	if len(st.history) == 0 {
+		return Step{Direction: Left, Box: NoBox}, false
+	}
+
+	step := st.revert()
+	st.future = append(st.future, step)
+
+	return step, true
+}
+
+// Redo performs the last undone step again and returns it.
+func (st *State) Redo() (Step, bool) {
+	if len(st.future) == 0 {
+		return Step{Direction: Left, Box: NoBox}, false
+	}
+
+	step := st.future[len(st.future)-1]
+	st.future = st.future[:len(st.future)-1]
+
+	st.apply(step)
+
+	return step, true
+}
+
+func (st *State) LastStep() (Step, bool) {
+	if len(st.history) == 0 {
+		return Step{Direction: Left, Box: NoBox}, false
+	}
+
+	return st.history[len(st.history)-1], true
+}
+
+func (st *State) CanRedo() bool {
+	return len(st.future) > 0
+}
+
+// WalksSinceLastPush counts steps made after the last push, or since start if there's none.
+func (st *State) WalksSinceLastPush() int {
+	count := 0
+
+	for i := len(st.history) - 1; i >= 0 && !st.history[i].Pushed(); i-- {
+		count++
+	}
+
+	return count
+}
+
+// StepsToLastPush counts undos needed to get back to before the last push.
+func (st *State) StepsToLastPush() int {
+	walks := st.WalksSinceLastPush()
+	if walks == len(st.history) {
+		return walks
+	}
+
+	return walks + 1
+}
+
+// Restart takes player and boxes back to where they started.
+// The position and history before it are kept for UndoRestart.
+func (st *State) Restart() bool {
+	if len(st.history) == 0 {
+		return false
+	}
+
+	st.restarts = append(st.restarts, snapshot{
+		boxes:   append([]Position(nil), st.boxes...),
+		player:  st.player,
+		history: append([]Step(nil), st.history...),
+		future:  append([]Step(nil), st.future...),
+	})
+
+	for len(st.history) > 0 {
+		st.revert()
+	}
+
+	st.future = st.future[:0]
+
+	return true
+}
+
+// CanUndoRestart tells whether nothing was done since the last restart.
+func (st *State) CanUndoRestart() bool {
+	return len(st.history) == 0 && len(st.restarts) > 0
+}
+
+// UndoRestart returns to the position and history right before the last restart.
+func (st *State) UndoRestart() bool {
+	if !st.CanUndoRestart() {
+		return false
+	}
+
+	last := st.restarts[len(st.restarts)-1]
+	st.restarts = st.restarts[:len(st.restarts)-1]
+
+	st.boxes = append(st.boxes[:0], last.boxes...)
+	st.reindex()
+	st.player = last.player
+	st.history = append(st.history[:0], last.history...)
+	st.future = append(st.future[:0], last.future...)
+
+	return true
+}
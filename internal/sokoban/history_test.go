@@ -0,0 +1,204 @@
+package sokoban
+
+import "testing"
+
+func TestUndoRedo(t *testing.T) {
+	start := []string{"#######", "#@ $ .#", "#######"}
+
+	tests := []struct {
+		name  string
+		moves []Direction
+		act   func(st *State) bool
+		ok    bool
+		want  string
+		redo  bool
+	}{
+		{
+			name:  "undo push",
+			moves: []Direction{Right, Right},
+			act:   func(st *State) bool { _, ok := st.Undo(); return ok },
+			ok:    true,
+			want:  xsb("#######", "# @$ .#", "#######"),
+			redo:  true,
+		},
+		{
+			name:  "undo nothing",
+			moves: nil,
+			act:   func(st *State) bool { _, ok := st.Undo(); return ok },
+			ok:    false,
+			want:  xsb(start...),
+			redo:  false,
+		},
+		{
+			name:  "redo",
+			moves: []Direction{Right, Right},
+			act: func(st *State) bool {
+				st.Undo()
+				st.Undo()
+				_, ok := st.Redo()
+
+				return ok
+			},
+			ok:   true,
+			want: xsb("#######", "# @$ .#", "#######"),
+			redo: true,
+		},
+		{
+			name:  "same move keeps redo",
+			moves: []Direction{Right, Right},
+			act: func(st *State) bool {
+				st.Undo()
+				st.Undo()
+
+				return st.Move(Right) == Walked
+			},
+			ok:   true,
+			want: xsb("#######", "# @$ .#", "#######"),
+			redo: true,
+		},
+		{
+			name:  "other move drops redo",
+			moves: []Direction{Right, Right},
+			act: func(st *State) bool {
+				st.Undo()
+
+				return st.Move(Left) == Walked
+			},
+			ok:   true,
+			want: xsb(start...),
+			redo: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, start...)
+
+			for _, dir := range tt.moves {
+				if st.Move(dir) == Blocked {
+					t.Fatalf("move %v is blocked", dir)
+				}
+			}
+
+			if got := tt.act(st); got != tt.ok {
+				t.Errorf("ok = %v, want %v", got, tt.ok)
+			}
+
+			if got := st.XSB(); got != tt.want {
+				t.Errorf("XSB() = %q, want %q", got, tt.want)
+			}
+
+			if got := st.CanRedo(); got != tt.redo {
+				t.Errorf("CanRedo() = %v, want %v", got, tt.redo)
+			}
+		})
+	}
+}
+func TestRestart(t *testing.T) {
+	start := []string{"#######", "#@ $ .#", "#######"}
+
+	tests := []struct {
+		name  string
+		moves []Direction
+		act   func(st *State) bool
+		ok    bool
+		want  string
+		redo  bool
+	}{
+		{
+			name:  "restart",
+			moves: []Direction{Right, Right, Right},
+			act:   func(st *State) bool { return st.Restart() },
+			ok:    true,
+			want:  xsb(start...),
+			redo:  false,
+		},
+		{
+			name:  "restart at start",
+			moves: nil,
+			act:   func(st *State) bool { return st.Restart() },
+			ok:    false,
+			want:  xsb(start...),
+			redo:  false,
+		},
+		{
+			name:  "undo restart",
+			moves: []Direction{Right, Right, Right, Left},
+			act: func(st *State) bool {
+				st.Undo()
+				st.Restart()
+
+				return st.UndoRestart()
+			},
+			ok:   true,
+			want: xsb("#######", "#   @*#", "#######"),
+			redo: true,
+		},
+		{
+			name:  "undo restart after a move",
+			moves: []Direction{Right, Right},
+			act: func(st *State) bool {
+				st.Restart()
+				st.Move(Right)
+
+				return st.UndoRestart()
+			},
+			ok:   false,
+			want: xsb("#######", "# @$ .#", "#######"),
+			redo: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, start...)
+
+			for _, dir := range tt.moves {
+				if st.Move(dir) == Blocked {
+					t.Fatalf("move %v is blocked", dir)
+				}
+			}
+
+			if got := tt.act(st); got != tt.ok {
+				t.Errorf("ok = %v, want %v", got, tt.ok)
+			}
+
+			if got := st.XSB(); got != tt.want {
+				t.Errorf("XSB() = %q, want %q", got, tt.want)
+			}
+
+			if got := st.CanRedo(); got != tt.redo {
+				t.Errorf("CanRedo() = %v, want %v", got, tt.redo)
+			}
+		})
+	}
+}
+
+func TestUndoRestartHistory(t *testing.T) {
+	st := parse(t, "#######", "#@ $ .#", "#######")
+
+	for _, dir := range []Direction{Right, Right, Right} {
+		st.Move(dir)
+	}
+
+	st.Undo()
+	st.Restart()
+
+	if !st.CanUndoRestart() {
+		t.Fatal("CanUndoRestart() = false after restart")
+	}
+
+	st.UndoRestart()
+
+	if got, want := st.LURD(), "rR"; got != want {
+		t.Errorf("LURD() = %q, want %q", got, want)
+	}
+
+	if _, ok := st.Redo(); !ok || st.LURD() != "rRR" {
+		t.Errorf("after Redo() LURD() = %q, want %q", st.LURD(), "rRR")
+	}
+
+	if st.CanUndoRestart() {
+		t.Error("CanUndoRestart() = true twice")
+	}
+}
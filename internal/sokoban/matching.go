@@ -0,0 +1,92 @@
+package sokoban
+
+import "math"
+
+const (
+	// noMatch is the cost of a box that can't reach a goal, above any sum of push distances.
+	noMatch = 1 << 20
+	// matchBound is above any reduced cost.
+	matchBound = math.MaxInt32
+)
+
+// matcher finds the least total cost of giving every box its own goal, with buffers reused between calls.
+type matcher struct {
+	boxes, goals int
+	u, v, minv   []int
+	match, way   []int
+	used         []bool
+}
+
+func newMatcher(boxes, goals int) *matcher {
+	return &matcher{
+		boxes: boxes,
+		goals: goals,
+		u:     make([]int, boxes+1),
+		v:     make([]int, goals+1),
+		minv:  make([]int, goals+1),
+		match: make([]int, goals+1),
+		way:   make([]int, goals+1),
+		used:  make([]bool, goals+1),
+	}
+}
+
+// minCost returns the least sum of costs[box][goal] over assignments of boxes to distinct goals,
+// noMatch or more when some box can't be given a goal. There must be at least as many goals as boxes.
+// It's the Hungarian method, rows and columns counted from one so zero is the free column.
+func (m *matcher) minCost(costs [][]int) int {
+	for j := range m.v {
+		m.v[j], m.match[j] = 0, 0
+	}
+
+	for i := range m.u {
+		m.u[i] = 0
+	}
+
+	for i := 1; i <= m.boxes; i++ {
+		m.match[0] = i
+		free := 0
+
+		for j := range m.minv {
+			m.minv[j], m.used[j] = matchBound, false
+		}
+
+		for m.match[free] != 0 {
+			m.used[free] = true
+			row, delta, next := m.match[free], matchBound, 0
+			cost := costs[row-1]
+
+			for j := 1; j <= m.goals; j++ {
+				if m.used[j] {
+					continue
+				}
+
+				if cur := cost[j-1] - m.u[row] - m.v[j]; cur < m.minv[j] {
+					m.minv[j], m.way[j] = cur, free
+				}
+
+				if m.minv[j] < delta {
+					delta, next = m.minv[j], j
+				}
+			}
+
+			for j := range m.used {
+				if m.used[j] {
+					m.u[m.match[j]] += delta
+					m.v[j] -= delta
+				} else {
+					m.minv[j] -= delta
+				}
+			}
+
+			free = next
+		}
+
+		for free != 0 {
+			prev := m.way[free]
+			m.match[free] = m.match[prev]
+			free = prev
+		}
+	}
+
+	return -m.v[0]
+}
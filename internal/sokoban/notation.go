@@ -0,0 +1,136 @@
+package sokoban
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidLURD = errors.New("invalid lurd character")
+
+func (dir Direction) lurd() rune {
+	switch dir {
+	case Left:
+		return 'l'
+	case Right:
+		return 'r'
+	case Up:
+		return 'u'
+	case Down:
+		return 'd'
+	default:
+		return '?'
+	}
+}
+
+// LURD encodes the history in LURD notation, lowercase for moves and uppercase for pushes.
+func (st *State) LURD() string {
+	var sb strings.Builder
+
+	for _, step := range st.history {
+		c := step.Direction.lurd()
+		if step.Pushed() {
+			c = unicode.ToUpper(c)
+		}
+
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
+
+// ParseLURD decodes LURD notation into directions, ignoring case and whitespace.
+func ParseLURD(s string) ([]Direction, error) {
+	res := make([]Direction, 0, len(s))
+
+	for i, c := range s {
+		if unicode.IsSpace(c) {
+			continue
+		}
+
+		switch unicode.ToLower(c) {
+		case 'l':
+			res = append(res, Left)
+		case 'r':
+			res = append(res, Right)
+		case 'u':
+			res = append(res, Up)
+		case 'd':
+			res = append(res, Down)
+		default:
+			return nil, errors.Wrapf(ErrInvalidLURD, "'%c' at %d", c, i)
+		}
+	}
+
+	return res, nil
+}
+
+// XSB encodes walls, goals, boxes and player in XSB notation, cropped to the occupied area.
+func (st *State) XSB() string {
+	minI, minJ, maxI, maxJ := st.width, st.height, -1, -1
+
+	for j := 0; j < st.height; j++ {
+		for i := 0; i < st.width; i++ {
+			pos := Position{I: i, J: j}
+			if !st.IsWall(pos) && !st.IsGoal(pos) && st.BoxAt(pos) == NoBox && st.player != pos {
+				continue
+			}
+
+			minI, minJ = minInt(minI, i), minInt(minJ, j)
+			maxI, maxJ = maxInt(maxI, i), maxInt(maxJ, j)
+		}
+	}
+
+	var sb strings.Builder
+
+	for j := minJ; j <= maxJ; j++ {
+		line := make([]rune, 0, maxI-minI+1)
+
+		for i := minI; i <= maxI; i++ {
+			line = append(line, st.xsbAt(Position{I: i, J: j}))
+		}
+
+		sb.WriteString(strings.TrimRight(string(line), " "))
+		sb.WriteRune('\n')
+	}
+
+	return sb.String()
+}
+
+func (st *State) xsbAt(pos Position) rune {
+	goal := st.IsGoal(pos)
+
+	switch {
+	case st.IsWall(pos):
+		return '#'
+	case st.player == pos && goal:
+		return '+'
+	case st.player == pos:
+		return '@'
+	case st.BoxAt(pos) != NoBox && goal:
+		return '*'
+	case st.BoxAt(pos) != NoBox:
+		return '$'
+	case goal:
+		return '.'
+	default:
+		return ' '
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
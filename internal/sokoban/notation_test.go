@@ -0,0 +1,109 @@
+package sokoban
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLURD(t *testing.T) {
+	st := parse(t, "######", "#@ $.#", "#    #", "######")
+
+	for _, dir := range []Direction{Down, Right, Up, Right, Left} {
+		if st.Move(dir) == Blocked {
+			t.Fatalf("move %v is blocked", dir)
+		}
+	}
+
+	if got, want := st.LURD(), "druRl"; got != want {
+		t.Errorf("LURD() = %q, want %q", got, want)
+	}
+}
+
+func TestParseLURD(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Direction
+		err  error
+	}{
+		{name: "empty", in: "", want: []Direction{}, err: nil},
+		{name: "moves and pushes", in: "lUrD", want: []Direction{Left, Up, Right, Down}, err: nil},
+		{name: "whitespace", in: " l r\n\tU ", want: []Direction{Left, Right, Up}, err: nil},
+		{name: "invalid", in: "lrx", want: nil, err: ErrInvalidLURD},
+		{name: "run length", in: "3l", want: nil, err: ErrInvalidLURD},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLURD(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseLURD() error = %v, want %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLURD() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLURDRoundTrip(t *testing.T) {
+	start := []string{"#######", "#@ $ .#", "#  $ .#", "#######"}
+	st := parse(t, start...)
+
+	for _, dir := range []Direction{Right, Right, Right, Left, Down, Right, Right} {
+		st.Move(dir)
+	}
+
+	dirs, err := ParseLURD(st.LURD())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := parse(t, start...)
+	for _, dir := range dirs {
+		replay.Move(dir)
+	}
+
+	if replay.LURD() != st.LURD() || replay.XSB() != st.XSB() {
+		t.Errorf("replay = %q %q, want %q %q", replay.LURD(), replay.XSB(), st.LURD(), st.XSB())
+	}
+}
+
+func TestXSB(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		want string
+	}{
+		{
+			name: "every cell",
+			rows: []string{"#######", "#@$.* #", "#######"},
+			want: xsb("#######", "#@$.* #", "#######"),
+		},
+		{
+			name: "player on goal",
+			rows: []string{"#####", "#+$ #", "#####"},
+			want: xsb("#####", "#+$ #", "#####"),
+		},
+		{
+			name: "cropped",
+			rows: []string{"         ", "  ####   ", "  #@*#   ", "  ####   ", "         "},
+			want: xsb("####", "#@*#", "####"),
+		},
+		{
+			name: "ragged rows",
+			rows: []string{"  ###", "###@#", "#.$ #", "#####"},
+			want: xsb("  ###", "###@#", "#.$ #", "#####"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(t, tt.rows...).XSB(); got != tt.want {
+				t.Errorf("XSB() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
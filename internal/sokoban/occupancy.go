@@ -0,0 +1,52 @@
+package sokoban
+
+// newOccupancy creates the grid mapping every cell to the index of the box on it,
+// so box lookups don't scan all boxes. It's kept up to date along with the count of boxes on goals.
+func newOccupancy(size int) []int {
+	res := make([]int, size)
+	for i := range res {
+		res[i] = NoBox
+	}
+
+	return res
+}
+
+func (st *State) occupy(pos Position, box int) {
+	if !st.Inside(pos) {
+		return
+	}
+
+	st.occupancy[st.index(pos)] = box
+
+	if st.goals[st.index(pos)] {
+		st.onGoal++
+	}
+}
+
+func (st *State) vacate(pos Position) {
+	if !st.Inside(pos) {
+		return
+	}
+
+	st.occupancy[st.index(pos)] = NoBox
+
+	if st.goals[st.index(pos)] {
+		st.onGoal--
+	}
+}
+
+func (st *State) moveBox(box int, to Position) {
+	st.vacate(st.boxes[box])
+	st.boxes[box] = to
+	st.occupy(to, box)
+}
+
+// reindex rebuilds occupancy after boxes are replaced at once.
+func (st *State) reindex() {
+	st.occupancy = newOccupancy(len(st.occupancy))
+	st.onGoal = 0
+
+	for i := range st.boxes {
+		st.occupy(st.boxes[i], i)
+	}
+}
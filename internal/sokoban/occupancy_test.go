@@ -0,0 +1,58 @@
+package sokoban
+
+import "testing"
+
+func TestOccupancy(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		moves   []Direction
+		restart bool
+		onGoal  int
+		solved  bool
+	}{
+		{name: "start", rows: []string{"#######", "#@$ .*#", "#######"}, moves: nil, restart: false, onGoal: 1, solved: false},
+		{name: "onto goal", rows: []string{"#######", "#@$.  #", "#######"}, moves: []Direction{Right}, restart: false, onGoal: 1, solved: true},
+		{name: "off goal", rows: []string{"#######", "#@*  .#", "#######"}, moves: []Direction{Right}, restart: false, onGoal: 0, solved: false},
+		{name: "across goal", rows: []string{"#######", "#@$. .#", "#######"}, moves: []Direction{Right, Right, Right}, restart: false, onGoal: 1, solved: true},
+		{name: "restart", rows: []string{"#######", "#@$.  #", "#######"}, moves: []Direction{Right, Right}, restart: true, onGoal: 0, solved: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, tt.rows...)
+
+			for _, dir := range tt.moves {
+				st.Move(dir)
+			}
+
+			if tt.restart {
+				st.Restart()
+			}
+
+			if got := st.BoxesOnGoal(); got != tt.onGoal {
+				t.Errorf("BoxesOnGoal() = %d, want %d", got, tt.onGoal)
+			}
+
+			if got := st.IsSolved(); got != tt.solved {
+				t.Errorf("IsSolved() = %v, want %v", got, tt.solved)
+			}
+
+			for i, pos := range st.Boxes() {
+				if got := st.BoxAt(pos); got != i {
+					t.Errorf("BoxAt(%v) = %d, want %d", pos, got, i)
+				}
+			}
+		})
+	}
+}
+
+func TestSetGoalUnderBox(t *testing.T) {
+	st := parse(t, "#####", "#@$ #", "#####")
+	st.SetGoal(Position{I: 2, J: 1})
+	st.SetGoal(Position{I: 2, J: 1})
+
+	if got := st.BoxesOnGoal(); got != 1 {
+		t.Errorf("BoxesOnGoal() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,691 @@
+package sokoban
+
+import (
+	"container/heap"
+	"context"
+	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsolvable  = errors.New("no solution")
+	ErrSearchLimit = errors.New("search gave up before finding a solution")
+)
+
+const (
+	unreachable = -1
+	// cancelCheckInterval is number of expanded nodes between context checks.
+	cancelCheckInterval = 1024
+	// maxSearchNodes bounds positions kept by a search, a few hundred bytes each with the garbage collector's headroom.
+	maxSearchNodes = 1 << 19
+)
+
+type searchNode struct {
+	key    string
+	player int
+	pushes int
+	cost   int
+	parent *searchNode
+	box    int
+	dir    Direction
+	tunnel bool
+}
+
+type searchQueue []*searchNode
+
+func (q searchQueue) Len() int {
+	return len(q)
+}
+
+func (q searchQueue) Less(i, j int) bool {
+	if q[i].cost == q[j].cost {
+		return q[i].pushes > q[j].pushes
+	}
+
+	return q[i].cost < q[j].cost
+}
+
+func (q searchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *searchQueue) Push(x interface{}) {
+	node, _ := x.(*searchNode)
+	*q = append(*q, node)
+}
+
+func (q *searchQueue) Pop() interface{} {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return node
+}
+
+// solver searches positions by cell index. Neighbors are unreachable across walls, and costs hold
+// the pushes from a cell to every goal for the matching, noMatch where it can't reach one.
+type solver struct {
+	st        *State
+	goals     []int
+	neighbors [][4]int
+	floor     []bool
+	corral    []int
+	costs     [][]int
+	rows      [][]int
+	distance  []int
+	boxAt     []bool
+	matcher   *matcher
+	room      *goalRoom
+	seen      map[string]int
+	visited   []int
+	cells     []int
+	marks     []int
+	mark      int
+}
+
+func (s *solver) isBox(pos Position) bool {
+	return s.st.Inside(pos) && s.boxAt[s.st.index(pos)]
+}
+
+// frozen tells whether a box pushed from cell to another cell gets stuck off goal.
+func (s *solver) frozen(from, to int) bool {
+	if s.st.goals[to] {
+		return false
+	}
+
+	s.boxAt[from], s.boxAt[to] = false, true
+	defer func() { s.boxAt[from], s.boxAt[to] = true, false }()
+
+	return s.st.isFrozen(s.st.position(to), s.isBox)
+}
+
+func (s *solver) step(cell int, dir Direction) int {
+	return s.neighbors[cell][dir]
+}
+
+// reachable marks cells the player can walk to without pushing and returns the smallest one.
+func (s *solver) reachable(from int, visited []int) int {
+	for i := range visited {
+		visited[i] = unreachable
+	}
+
+	visited[from] = from
+	queue := append(s.cells[:0], from)
+	smallest := from
+
+	for k := 0; k < len(queue); k++ {
+		cell := queue[k]
+		if cell < smallest {
+			smallest = cell
+		}
+
+		for _, next := range s.neighbors[cell] {
+			if next == unreachable || s.boxAt[next] || visited[next] != unreachable {
+				continue
+			}
+
+			visited[next] = cell
+			queue = append(queue, next)
+		}
+	}
+
+	s.cells = queue
+
+	return smallest
+}
+
+// normalized returns the smallest cell the player can walk to from a cell, like reachable without
+// keeping the cells, which are told apart from earlier calls by mark.
+func (s *solver) normalized(from int) int {
+	s.mark++
+	s.marks[from] = s.mark
+	queue := append(s.cells[:0], from)
+	smallest := from
+
+	for k := 0; k < len(queue); k++ {
+		cell := queue[k]
+		if cell < smallest {
+			smallest = cell
+		}
+
+		for _, next := range s.neighbors[cell] {
+			if next == unreachable || s.boxAt[next] || s.marks[next] == s.mark {
+				continue
+			}
+
+			s.marks[next] = s.mark
+			queue = append(queue, next)
+		}
+	}
+
+	s.cells = queue
+
+	return smallest
+}
+
+// heuristic returns the least pushes bringing every box to its own goal, ignoring other boxes,
+// or noMatch and more when they can't all get one.
+func (s *solver) heuristic(boxes []int) int {
+	for i, box := range boxes {
+		s.rows[i] = s.costs[box]
+	}
+
+	return s.matcher.minCost(s.rows)
+}
+
+func (s *solver) solved(boxes []int) bool {
+	for _, box := range boxes {
+		if !s.st.goals[box] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stateKey packs sorted box cells and the normalized player cell, two bytes each.
+func stateKey(boxes []int, player int) string {
+	key := make([]byte, 0, 2*len(boxes)+2)
+	for _, cell := range boxes {
+		key = append(key, byte(cell>>8), byte(cell))
+	}
+
+	key = append(key, byte(player>>8), byte(player))
+
+	return string(key)
+}
+
+// keyBoxes unpacks the box cells of a state key into boxes.
+func keyBoxes(key string, boxes []int) {
+	for i := range boxes {
+		boxes[i] = int(key[2*i])<<8 | int(key[2*i+1])
+	}
+}
+
+// Solve searches a solution with the fewest pushes from the current position of st, except boxes
+// entering a goal room go straight to the goal its fill order gives them.
+// It returns every step, walking included, and doesn't modify st. It gives up with ErrSearchLimit
+// after maxSearchNodes positions, as they're all kept in memory.
+func Solve(ctx context.Context, st *State) ([]Direction, error) {
+	if len(st.boxes) > len(st.Goals()) {
+		return nil, ErrUnsolvable
+	}
+
+	s := newSolver(st)
+
+	boxes := make([]int, len(st.boxes))
+
+	for i := range st.boxes {
+		if !st.Inside(st.boxes[i]) {
+			return nil, ErrUnsolvable
+		}
+
+		boxes[i] = st.index(st.boxes[i])
+		if s.distance[boxes[i]] == unreachable {
+			return nil, ErrUnsolvable
+		}
+	}
+
+	sort.Ints(boxes)
+
+	for _, box := range boxes {
+		s.boxAt[box] = true
+	}
+
+	start := &searchNode{
+		key:    stateKey(boxes, s.normalized(st.index(st.player))),
+		player: st.index(st.player),
+		pushes: 0,
+		cost:   s.heuristic(boxes),
+		parent: nil,
+		box:    NoBox,
+		dir:    Left,
+		tunnel: false,
+	}
+
+	for _, box := range boxes {
+		s.boxAt[box] = false
+	}
+
+	if start.cost >= noMatch {
+		return nil, ErrUnsolvable
+	}
+
+	s.seen[start.key] = 0
+	queue := &searchQueue{start}
+	expanded := 0
+
+	for queue.Len() > 0 {
+		expanded++
+		if expanded%cancelCheckInterval == 0 {
+			// js/wasm runs goroutines on a single thread, leave the game some of it
+			runtime.Gosched()
+
+			if err := ctx.Err(); err != nil {
+				return nil, errors.Wrap(err, "error on search")
+			}
+		}
+
+		node, _ := heap.Pop(queue).(*searchNode)
+		if node.pushes > s.seen[node.key] {
+			continue
+		}
+
+		keyBoxes(node.key, boxes)
+
+		if s.solved(boxes) {
+			return s.path(node), nil
+		}
+
+		for _, box := range boxes {
+			s.boxAt[box] = true
+		}
+
+		s.reachable(node.player, s.visited)
+		err := s.expand(node, boxes, queue)
+
+		for _, box := range boxes {
+			s.boxAt[box] = false
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, ErrUnsolvable
+}
+
+func newSolver(st *State) *solver {
+	s := &solver{
+		st:        st,
+		goals:     make([]int, 0),
+		neighbors: make([][4]int, len(st.walls)),
+		floor:     st.walkable(st.index(st.player), unreachable),
+		corral:    make([]int, len(st.walls)),
+		costs:     make([][]int, len(st.walls)),
+		rows:      make([][]int, len(st.boxes)),
+		distance:  st.pushDistances(),
+		boxAt:     make([]bool, len(st.walls)),
+		matcher:   nil,
+		room:      nil,
+		seen:      make(map[string]int),
+		visited:   make([]int, len(st.walls)),
+		cells:     make([]int, 0, len(st.walls)),
+		marks:     make([]int, len(st.walls)),
+		mark:      0,
+	}
+
+	for cell := range st.walls {
+		for _, dir := range Directions() {
+			s.neighbors[cell][dir] = st.step(cell, dir)
+		}
+
+		if st.goals[cell] && !st.walls[cell] {
+			s.goals = append(s.goals, cell)
+		}
+
+		s.costs[cell] = make([]int, 0)
+	}
+
+	for _, goal := range s.goals {
+		distances := st.pullDistances([]int{goal})
+
+		for cell, d := range distances {
+			if d == unreachable {
+				d = noMatch
+			}
+
+			s.costs[cell] = append(s.costs[cell], d)
+		}
+	}
+
+	s.matcher = newMatcher(len(st.boxes), len(s.goals))
+	s.room = st.findGoalRoom(s.goals)
+
+	return s
+}
+
+// expand queues positions after every push the player can make in node, whose boxes are marked and
+// cells reachable by the player visited. After a push into a tunnel only pushing on is tried, with a
+// PI-corral only pushes into it, and while the goal room is filled in order boxes in it stay and boxes
+// entering it go to the next goal.
+func (s *solver) expand(node *searchNode, boxes []int, queue *searchQueue) error {
+	if node.tunnel {
+		box := s.step(node.player, node.dir)
+		if s.canPush(box, node.dir) {
+			return s.queue(node, boxes, []push{{box: box, dir: node.dir}}, queue)
+		}
+	}
+
+	filled := unreachable
+	if s.room != nil {
+		filled = s.room.filled(s.boxAt)
+	}
+
+	pushes := s.corralPushes()
+
+	for _, p := range pushes {
+		// boxes put in the goal room stay, so they don't count as a way in
+		if filled != unreachable && s.room.cells[p.box] {
+			pushes = nil
+
+			break
+		}
+	}
+
+	if pushes == nil {
+		pushes = make([]push, 0, len(boxes)*len(Directions()))
+
+		for _, box := range boxes {
+			for _, dir := range Directions() {
+				pushes = append(pushes, push{box: box, dir: dir})
+			}
+		}
+	}
+
+	for _, p := range pushes {
+		if !s.canPush(p.box, p.dir) || (filled != unreachable && s.room.cells[p.box]) {
+			continue
+		}
+
+		moves := []push{p}
+		if filled != unreachable && filled < len(s.room.fill) && s.entering(p.box, p.dir) {
+			moves = s.enterRoom(p.box, p.dir, s.room.fill[filled])
+		}
+
+		if err := s.queue(node, boxes, moves, queue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// canPush tells whether the player can push the box at cell towards dir without a deadlock.
+func (s *solver) canPush(box int, dir Direction) bool {
+	from := s.step(box, dir.Opposite())
+	to := s.step(box, dir)
+
+	if from == unreachable || to == unreachable || s.visited[from] == unreachable || s.boxAt[to] || s.distance[to] == unreachable {
+		return false
+	}
+
+	return !s.frozen(box, to)
+}
+
+// entering tells whether pushing the box at cell towards dir takes it into the goal room, to the
+// entrance facing inside or from the entrance in.
+func (s *solver) entering(box int, dir Direction) bool {
+	to := s.step(box, dir)
+	if to == s.room.entrance {
+		next := s.step(to, dir)
+
+		return next != unreachable && s.room.cells[next]
+	}
+
+	return box == s.room.entrance && s.room.cells[to]
+}
+
+// enterRoom returns the fewest pushes taking the box at cell pushed towards dir through the entrance
+// to goal, or the single push if the way is blocked. Boxes of the node are marked.
+func (s *solver) enterRoom(box int, dir Direction, goal int) []push {
+	single := []push{{box: box, dir: dir}}
+
+	first, start, player := []push(nil), box, s.step(box, dir.Opposite())
+	if s.step(box, dir) == s.room.entrance {
+		first, start, player = single, s.room.entrance, box
+	}
+
+	type state struct{ box, player int }
+
+	area := make([]int, len(s.visited))
+
+	// the player is kept as the smallest cell it can walk to
+	stateAt := func(box, player int) state {
+		s.boxAt[box] = true
+		defer func() { s.boxAt[box] = false }()
+
+		return state{box: box, player: s.reachable(player, area)}
+	}
+
+	s.boxAt[box] = false
+	defer func() { s.boxAt[box] = true }()
+
+	begin := stateAt(start, player)
+	parents := map[state]state{begin: begin}
+	moves := map[state]push{}
+	queue := []state{begin}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur.box == goal {
+			res := make([]push, 0)
+			for ; cur != begin; cur = parents[cur] {
+				res = append(res, moves[cur])
+			}
+
+			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+				res[i], res[j] = res[j], res[i]
+			}
+
+			return append(first, res...)
+		}
+
+		stateAt(cur.box, cur.player)
+
+		pushes := make([]push, 0, len(Directions()))
+
+		for _, d := range Directions() {
+			from, to := s.step(cur.box, d.Opposite()), s.step(cur.box, d)
+			if from != unreachable && to != unreachable && area[from] != unreachable && !s.boxAt[to] && s.room.cells[to] {
+				pushes = append(pushes, push{box: cur.box, dir: d})
+			}
+		}
+
+		for _, p := range pushes {
+			next := stateAt(s.step(p.box, p.dir), p.box)
+			if _, ok := parents[next]; ok {
+				continue
+			}
+
+			parents[next], moves[next] = cur, p
+			queue = append(queue, next)
+		}
+	}
+
+	return single
+}
+
+// queue queues the position after moves, pushes of a single box, unless it was reached with as few
+// pushes before or every box can't get its own goal anymore. Boxes of node are marked.
+func (s *solver) queue(node *searchNode, boxes []int, moves []push, queue *searchQueue) error {
+	first, last := moves[0], moves[len(moves)-1]
+	to := s.step(last.box, last.dir)
+
+	moved := append([]int(nil), boxes...)
+	for i := range moved {
+		if moved[i] == first.box {
+			moved[i] = to
+		}
+	}
+
+	sort.Ints(moved)
+
+	s.boxAt[first.box], s.boxAt[to] = false, true
+	key := stateKey(moved, s.normalized(last.box))
+	s.boxAt[first.box], s.boxAt[to] = true, false
+
+	pushes := node.pushes + len(moves)
+
+	if seen, ok := s.seen[key]; ok && seen <= pushes {
+		return nil
+	}
+
+	h := s.heuristic(moved)
+	if h >= noMatch {
+		return nil
+	}
+
+	if len(s.seen) >= maxSearchNodes {
+		return ErrSearchLimit
+	}
+
+	s.seen[key] = pushes
+
+	parent := node
+	for _, move := range moves[:len(moves)-1] {
+		parent = &searchNode{
+			key:    "",
+			player: move.box,
+			pushes: parent.pushes + 1,
+			cost:   0,
+			parent: parent,
+			box:    move.box,
+			dir:    move.dir,
+			tunnel: false,
+		}
+	}
+
+	heap.Push(queue, &searchNode{
+		key:    key,
+		player: last.box,
+		pushes: pushes,
+		cost:   pushes + h,
+		parent: parent,
+		box:    last.box,
+		dir:    last.dir,
+		tunnel: len(moves) == 1 && s.inTunnel(last.box, to, last.dir),
+	})
+
+	return nil
+}
+
+// inTunnel tells whether a box pushed towards dir from a cell to another is off goal with walls on
+// both sides of it and of the player behind it, so pushing it on can't be worse than anything else.
+func (s *solver) inTunnel(from, to int, dir Direction) bool {
+	if s.st.goals[to] {
+		return false
+	}
+
+	for _, side := range Directions() {
+		if side == dir || side == dir.Opposite() {
+			continue
+		}
+
+		if s.step(from, side) != unreachable || s.step(to, side) != unreachable {
+			return false
+		}
+	}
+
+	return true
+}
+
+// path replays pushes leading to node and fills in walks between them.
+func (s *solver) path(node *searchNode) []Direction {
+	pushes := make([]*searchNode, 0, node.pushes)
+	for ; node.parent != nil; node = node.parent {
+		pushes = append(pushes, node)
+	}
+
+	replay := s.st.Clone()
+	replay.history = replay.history[:0]
+	replay.future = replay.future[:0]
+	visited := make([]int, len(s.st.walls))
+	res := make([]Direction, 0)
+
+	for i := len(pushes) - 1; i >= 0; i-- {
+		for cell := range s.boxAt {
+			s.boxAt[cell] = false
+		}
+
+		for _, box := range replay.boxes {
+			s.boxAt[replay.index(box)] = true
+		}
+
+		player := replay.index(replay.player)
+		target := s.step(pushes[i].box, pushes[i].dir.Opposite())
+
+		s.reachable(player, visited)
+
+		walk := make([]Direction, 0)
+
+		for cell := target; cell != player; cell = visited[cell] {
+			walk = append(walk, s.direction(visited[cell], cell))
+		}
+
+		for j := len(walk) - 1; j >= 0; j-- {
+			replay.Move(walk[j])
+			res = append(res, walk[j])
+		}
+
+		replay.Move(pushes[i].dir)
+		res = append(res, pushes[i].dir)
+	}
+
+	return res
+}
+
+func (s *solver) direction(from, to int) Direction {
+	for _, dir := range Directions() {
+		if s.step(from, dir) == to {
+			return dir
+		}
+	}
+
+	return Left
+}
+
+// pushDistances returns for every cell the least pushes needed to bring a box on it to any goal,
+// ignoring other boxes, or -1 when no goal is reachable.
+func (st *State) pushDistances() []int {
+	goals := make([]int, 0)
+
+	for i := range st.goals {
+		if st.goals[i] && !st.walls[i] {
+			goals = append(goals, i)
+		}
+	}
+
+	return st.pullDistances(goals)
+}
+
+// pullDistances returns for every cell the least pushes needed to bring a box on it to one of
+// targets, ignoring other boxes, or -1 when none is reachable. It pulls boxes back from the targets.
+func (st *State) pullDistances(targets []int) []int {
+	res := make([]int, len(st.walls))
+	for i := range res {
+		res[i] = unreachable
+	}
+
+	queue := make([]int, 0, len(targets))
+
+	for _, cell := range targets {
+		res[cell] = 0
+		queue = append(queue, cell)
+	}
+
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		pos := st.position(cell)
+
+		for _, dir := range Directions() {
+			box := pos.Next(dir.Opposite())
+			player := box.Next(dir.Opposite())
+
+			if st.IsWall(box) || st.IsWall(player) || res[st.index(box)] != unreachable {
+				continue
+			}
+
+			res[st.index(box)] = res[cell] + 1
+			queue = append(queue, st.index(box))
+		}
+	}
+
+	return res
+}
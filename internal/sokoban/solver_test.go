@@ -0,0 +1,114 @@
+package sokoban
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   []string
+		pushes int
+		err    error
+	}{
+		{
+			name:   "solved",
+			rows:   []string{"#####", "#@* #", "#####"},
+			pushes: 0,
+			err:    nil,
+		},
+		{
+			name:   "one push",
+			rows:   []string{"#####", "#@$.#", "#####"},
+			pushes: 1,
+			err:    nil,
+		},
+		{
+			name:   "corridor",
+			rows:   []string{"#######", "#.  $@#", "#######"},
+			pushes: 3,
+			err:    nil,
+		},
+		{
+			name:   "around a corner",
+			rows:   []string{"######", "#    #", "# $  #", "#.@  #", "######"},
+			pushes: 2,
+			err:    nil,
+		},
+		{
+			name: "original level 1",
+			rows: []string{
+				"    #####",
+				"    #   #",
+				"    #$  #",
+				"  ###  $##",
+				"  #  $ $ #",
+				"### # ## #   ######",
+				"#   # ## #####  ..#",
+				"# $  $          ..#",
+				"##### ### #@##  ..#",
+				"    #     #########",
+				"    #######",
+			},
+			pushes: 97,
+			err:    nil,
+		},
+		{
+			name:   "box in corner",
+			rows:   []string{"#####", "#$  #", "# @.#", "#####"},
+			pushes: 0,
+			err:    ErrUnsolvable,
+		},
+		{
+			name:   "boxes in a row",
+			rows:   []string{"########", "#@ $$..#", "########"},
+			pushes: 0,
+			err:    ErrUnsolvable,
+		},
+		{
+			name:   "more boxes than goals",
+			rows:   []string{"######", "#@$$.#", "#    #", "######"},
+			pushes: 0,
+			err:    ErrUnsolvable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, tt.rows...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			dirs, err := Solve(ctx, st)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Solve() error = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if st.Moves() != 0 {
+				t.Error("Solve() modified the state")
+			}
+
+			for _, dir := range dirs {
+				if st.Move(dir) == Blocked {
+					t.Fatalf("move %v is blocked after %q", dir, st.LURD())
+				}
+			}
+
+			if !st.IsSolved() {
+				t.Errorf("solution %q doesn't solve the stage", st.LURD())
+			}
+
+			if got := st.Pushes(); got != tt.pushes {
+				t.Errorf("pushes = %d, want %d", got, tt.pushes)
+			}
+		})
+	}
+}
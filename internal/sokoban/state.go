@@ -0,0 +1,229 @@
+package sokoban
+
+// NoBox is the box index of a step that didn't push anything.
+const NoBox = -1
+
+type Result int
+
+const (
+	Blocked Result = iota
+	Walked
+	Pushed
+)
+
+type Step struct {
+	Direction Direction
+	Box       int
+}
+
+func (step Step) Pushed() bool {
+	return step.Box != NoBox
+}
+
+// State holds a puzzle and the position of its player and boxes.
+// Cells outside of the grid are treated as walls.
+type State struct {
+	width, height int
+	walls         []bool
+	goals         []bool
+	dead          []bool
+	boxes         []Position
+	occupancy     []int
+	onGoal        int
+	player        Position
+	history       []Step
+	future        []Step
+	restarts      []snapshot
+}
+
+func New(width, height int) *State {
+	return &State{
+		width:     width,
+		height:    height,
+		walls:     make([]bool, width*height),
+		goals:     make([]bool, width*height),
+		dead:      nil,
+		boxes:     make([]Position, 0),
+		occupancy: newOccupancy(width * height),
+		onGoal:    0,
+		player:    Position{I: 0, J: 0},
+		history:   make([]Step, 0),
+		future:    make([]Step, 0),
+		restarts:  make([]snapshot, 0),
+	}
+}
+
+func (st *State) Clone() *State {
+	return &State{
+		width:     st.width,
+		height:    st.height,
+		walls:     append([]bool(nil), st.walls...),
+		goals:     append([]bool(nil), st.goals...),
+		dead:      st.dead,
+		boxes:     append([]Position(nil), st.boxes...),
+		occupancy: append([]int(nil), st.occupancy...),
+		onGoal:    st.onGoal,
+		player:    st.player,
+		history:   append([]Step(nil), st.history...),
+		future:    append([]Step(nil), st.future...),
+		restarts:  append([]snapshot(nil), st.restarts...),
+	}
+}
+
+func (st *State) Width() int {
+	return st.width
+}
+
+func (st *State) Height() int {
+	return st.height
+}
+
+func (st *State) Inside(pos Position) bool {
+	return pos.I >= 0 && pos.J >= 0 && pos.I < st.width && pos.J < st.height
+}
+
+func (st *State) index(pos Position) int {
+	return pos.J*st.width + pos.I
+}
+
+func (st *State) position(index int) Position {
+	return Position{I: index % st.width, J: index / st.width}
+}
+
+func (st *State) SetWall(pos Position) {
+	if st.Inside(pos) {
+		st.walls[st.index(pos)] = true
+		st.dead = nil
+	}
+}
+
+func (st *State) SetGoal(pos Position) {
+	if !st.Inside(pos) || st.goals[st.index(pos)] {
+		return
+	}
+
+	st.goals[st.index(pos)] = true
+	st.dead = nil
+
+	if st.BoxAt(pos) != NoBox {
+		st.onGoal++
+	}
+}
+
+// AddBox places a new box and returns its index.
+func (st *State) AddBox(pos Position) int {
+	st.boxes = append(st.boxes, pos)
+	index := len(st.boxes) - 1
+
+	st.occupy(pos, index)
+
+	return index
+}
+
+func (st *State) SetPlayer(pos Position) {
+	st.player = pos
+}
+
+func (st *State) IsWall(pos Position) bool {
+	return !st.Inside(pos) || st.walls[st.index(pos)]
+}
+
+func (st *State) IsGoal(pos Position) bool {
+	return st.Inside(pos) && st.goals[st.index(pos)]
+}
+
+// BoxAt returns index of the box at pos or NoBox.
+func (st *State) BoxAt(pos Position) int {
+	if !st.Inside(pos) {
+		return NoBox
+	}
+
+	return st.occupancy[st.index(pos)]
+}
+
+func (st *State) Player() Position {
+	return st.player
+}
+
+func (st *State) Box(index int) Position {
+	return st.boxes[index]
+}
+
+func (st *State) Boxes() []Position {
+	return append([]Position(nil), st.boxes...)
+}
+
+func (st *State) Goals() []Position {
+	res := make([]Position, 0)
+
+	for j := 0; j < st.height; j++ {
+		for i := 0; i < st.width; i++ {
+			if st.goals[j*st.width+i] {
+				res = append(res, Position{I: i, J: j})
+			}
+		}
+	}
+
+	return res
+}
+
+func (st *State) History() []Step {
+	return append([]Step(nil), st.history...)
+}
+
+func (st *State) Moves() int {
+	return len(st.history)
+}
+
+func (st *State) Pushes() int {
+	pushes := 0
+
+	for i := range st.history {
+		if st.history[i].Pushed() {
+			pushes++
+		}
+	}
+
+	return pushes
+}
+
+// Move moves the player one cell towards dir, pushing a box if there is one in the way.
+// Redo steps are kept only if the move is the next one of them.
+func (st *State) Move(dir Direction) Result {
+	next := st.player.Next(dir)
+	if st.IsWall(next) {
+		return Blocked
+	}
+
+	box := st.BoxAt(next)
+	if box != NoBox {
+		beyond := next.Next(dir)
+		if st.IsWall(beyond) || st.BoxAt(beyond) != NoBox {
+			return Blocked
+		}
+	}
+
+	step := Step{Direction: dir, Box: box}
+
+	if len(st.future) > 0 && st.future[len(st.future)-1] == step {
+		st.future = st.future[:len(st.future)-1]
+	} else {
+		st.future = st.future[:0]
+	}
+
+	st.apply(step)
+
+	if box != NoBox {
+		return Pushed
+	}
+
+	return Walked
+}
+
+func (st *State) BoxesOnGoal() int {
+	return st.onGoal
+}
+
+func (st *State) IsSolved() bool {
+	return st.onGoal == len(st.boxes)
+}
@@ -0,0 +1,81 @@
+package sokoban
+
+import (
+	"strings"
+	"testing"
+)
+
+// parse builds a state from XSB rows.
+func parse(t *testing.T, rows ...string) *State {
+	t.Helper()
+
+	width := 0
+	for _, row := range rows {
+		width = maxInt(width, len(row))
+	}
+
+	st := New(width, len(rows))
+
+	for j, row := range rows {
+		for i, c := range row {
+			pos := Position{I: i, J: j}
+
+			switch c {
+			case '#':
+				st.SetWall(pos)
+			case '.':
+				st.SetGoal(pos)
+			case '$':
+				st.AddBox(pos)
+			case '*':
+				st.SetGoal(pos)
+				st.AddBox(pos)
+			case '@':
+				st.SetPlayer(pos)
+			case '+':
+				st.SetGoal(pos)
+				st.SetPlayer(pos)
+			case ' ', '-', '_':
+			default:
+				t.Fatalf("unexpected %q in row %d", c, j)
+			}
+		}
+	}
+
+	return st
+}
+
+func xsb(rows ...string) string {
+	return strings.Join(rows, "\n") + "\n"
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   []string
+		dir    Direction
+		result Result
+		want   string
+	}{
+		{name: "walk", rows: []string{"#####", "#@ .#", "#####"}, dir: Right, result: Walked, want: xsb("#####", "# @.#", "#####")},
+		{name: "wall", rows: []string{"#####", "#@ .#", "#####"}, dir: Left, result: Blocked, want: xsb("#####", "#@ .#", "#####")},
+		{name: "push", rows: []string{"#####", "#@$.#", "#####"}, dir: Right, result: Pushed, want: xsb("#####", "# @*#", "#####")},
+		{name: "box into wall", rows: []string{"####", "#@$#", "####"}, dir: Right, result: Blocked, want: xsb("####", "#@$#", "####")},
+		{name: "box into box", rows: []string{"######", "#@$$.#", "######"}, dir: Right, result: Blocked, want: xsb("######", "#@$$.#", "######")},
+		{name: "off goal", rows: []string{"#####", "#+$.#", "#####"}, dir: Right, result: Pushed, want: xsb("#####", "#.@*#", "#####")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := parse(t, tt.rows...)
+
+			if got := st.Move(tt.dir); got != tt.result {
+				t.Errorf("Move() = %v, want %v", got, tt.result)
+			}
+
+			if got := st.XSB(); got != tt.want {
+				t.Errorf("XSB() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
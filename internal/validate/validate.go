@@ -0,0 +1,102 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/sokoban"
+	"github.com/pkg/errors"
+)
+
+// DefaultSolveTimeout is the time budget of the solver per stage when none is given.
+const DefaultSolveTimeout = 10 * time.Second
+
+var ErrInvalidStages = errors.New("some stages are invalid or unsolvable")
+
+// Pack writes a line for every stage of pack, with its problems and, when solve is set, the move and push
+// counts of a solution found within timeout. It returns ErrInvalidStages if any stage is invalid or unsolvable.
+func Pack(w io.Writer, pack level.Pack, solve bool, timeout time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := false
+
+	_, _ = fmt.Fprintln(tw, "STAGE\tRESULT\tMOVES\tPUSHES\tTIME\tNOTE")
+
+	for i := range pack.Stages {
+		if !report(tw, pack.Stages[i], solve, timeout) {
+			failed = true
+		}
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return errors.Wrap(err, "error on flush report")
+	}
+
+	if failed {
+		return ErrInvalidStages
+	}
+
+	return nil
+}
+
+// report writes a line for the stage followed by its problems, and returns false if the stage is invalid or unsolvable.
+func report(w io.Writer, stg level.Stage, solve bool, timeout time.Duration) bool {
+	problems := stg.Problems()
+	if len(problems) > 0 {
+		_, _ = fmt.Fprintf(w, "%s\tINVALID\n", stg.Name)
+
+		for _, problem := range problems {
+			_, _ = fmt.Fprintf(w, "\t\t%v\n", problem)
+		}
+
+		return false
+	}
+
+	state := stg.NewState()
+	note := ""
+
+	// spare flags load fine in the game, but a finished pack should have none
+	if boxes, goals := len(state.Boxes()), len(state.Goals()); boxes != goals {
+		note = fmt.Sprintf("%d boxes, %d flags", boxes, goals)
+	}
+
+	if !solve {
+		_, _ = fmt.Fprintf(w, "%s\tOK\t\t\t\t%s\n", stg.Name, note)
+
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	solution, err := sokoban.Solve(ctx, state)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	switch {
+	case err == nil:
+		pushes := 0
+
+		for _, dir := range solution {
+			if state.Move(dir) == sokoban.Pushed {
+				pushes++
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\tSOLVED\t%d\t%d\t%v\t%s\n", stg.Name, len(solution), pushes, elapsed, note)
+
+		return true
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, sokoban.ErrSearchLimit):
+		_, _ = fmt.Fprintf(w, "%s\tUNKNOWN\t-\t-\t%v\t%s\n", stg.Name, elapsed, note)
+
+		return true
+	default:
+		_, _ = fmt.Fprintf(w, "%s\tUNSOLVABLE\t-\t-\t%v\t%s\n", stg.Name, elapsed, note)
+
+		return false
+	}
+}
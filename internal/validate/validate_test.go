@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/pkg/errors"
+)
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name   string
+		xsb    string
+		solve  bool
+		result string
+		err    error
+	}{
+		{name: "valid", xsb: "#####\n#@$.#\n#####\n", solve: false, result: "OK", err: nil},
+		{name: "solved", xsb: "#####\n#@$.#\n#####\n", solve: true, result: "SOLVED  1      1", err: nil},
+		{name: "invalid", xsb: "#####\n#@$$.#\n#####\n", solve: true, result: "INVALID", err: ErrInvalidStages},
+		{name: "unsolvable", xsb: "######\n#$ @.#\n######\n", solve: true, result: "UNSOLVABLE", err: ErrInvalidStages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := level.ParseXSB("1", strings.NewReader(tt.xsb))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pack := level.Pack{
+				ID:       "",
+				Title:    "",
+				Author:   "",
+				License:  "",
+				Credits:  nil,
+				Sections: []level.Section{{Title: "", Start: 0, End: len(stages)}},
+				Stages:   stages,
+			}
+
+			var out bytes.Buffer
+
+			err = Pack(&out, pack, tt.solve, time.Second)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Pack() error = %v, want %v", err, tt.err)
+			}
+
+			lines := strings.Split(out.String(), "\n")
+			if !strings.HasPrefix(lines[1], "1      "+tt.result) {
+				t.Errorf("report = %q, want stage 1 %s", out.String(), tt.result)
+			}
+		})
+	}
+}
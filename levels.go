@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+)
+
+// levelPaths collects every -levels flag.
+type levelPaths []string
+
+func (paths *levelPaths) String() string {
+	return strings.Join(*paths, ",")
+}
+
+func (paths *levelPaths) Set(value string) error {
+	*paths = append(*paths, value)
+
+	return nil
+}
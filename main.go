@@ -2,17 +2,63 @@ package main
 
 import (
 	"embed"
+	"flag"
+	"fmt"
+	"log"
+	"os"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/nasermirzaei89/shove-it/internal/game"
+	"github.com/nasermirzaei89/shove-it/internal/level"
 	"github.com/pkg/errors"
 )
 
 //go:embed assets
 var assets embed.FS
 
+var errModNotDir = errors.New("mod is not a directory")
+
 func main() {
-	game1, err := game.New(assets)
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		if err := validateCommand(os.Args[2:]); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	var paths levelPaths
+
+	flag.Var(&paths, "levels", "directory, pack manifest or stage file of extra levels, can be repeated")
+	mod := flag.String("mod", "", "directory of files overriding the built-in assets, laid out like assets")
+	flag.Parse()
+
+	overlay := game.NewOverlay(assets)
+	if *mod != "" {
+		if err := checkModDir(*mod); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		overlay.Mount("assets", os.DirFS(*mod))
+	}
+
+	packs := make([]level.Pack, 0, len(paths))
+
+	for _, path := range paths {
+		pack, err := level.LoadPackPath(path)
+		if err != nil {
+			// the built-in stages are still playable
+			log.Printf("skipping levels: %v", err)
+
+			continue
+		}
+
+		packs = append(packs, pack)
+	}
+
+	game1, err := game.New(overlay, packs...)
 	if err != nil {
 		panic(errors.Wrap(err, "error on new game"))
 	}
@@ -21,3 +67,17 @@ func main() {
 		panic(errors.Wrap(err, "error on run game"))
 	}
 }
+
+// checkModDir makes sure the mod directory exists, so a mistyped path isn't silently ignored.
+func checkModDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return errors.Wrap(err, "error on open mod directory")
+	}
+
+	if !info.IsDir() {
+		return errors.Wrapf(errModNotDir, "'%s'", dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/nasermirzaei89/shove-it/internal/level"
+	"github.com/nasermirzaei89/shove-it/internal/validate"
+	"github.com/pkg/errors"
+)
+
+// validateCommand implements `shove-it validate [-solve] [-timeout 10s] [dir|file]`.
+// Without a path the built-in stages embedded in the binary are checked.
+func validateCommand(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	solve := flags.Bool("solve", false, "run the solver on every valid stage")
+	timeout := flags.Duration("timeout", validate.DefaultSolveTimeout, "time budget of the solver per stage")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, "error on parse flags")
+	}
+
+	var pack level.Pack
+
+	if flags.NArg() > 0 {
+		pack, err = level.LoadPackPath(flags.Arg(0))
+	} else {
+		pack, err = level.LoadPack(assets, "assets/stages")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "error on load pack")
+	}
+
+	return validate.Pack(os.Stdout, pack, *solve, *timeout)
+}